@@ -0,0 +1,112 @@
+package comic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AddAlias registers aliases of the passed command name
+// i.e. loading config for any of the aliases loads config for the command
+func AddAlias(commandName string, aliases ...string) { c.AddAlias(commandName, aliases...) }
+func (c *Comic) AddAlias(commandName string, aliases ...string) {
+	if c.aliases == nil {
+		c.aliases = make(map[string]string)
+	}
+
+	for _, alias := range aliases {
+		c.aliases[alias] = commandName
+	}
+}
+
+// resolveCommandName returns the name of the command the passed command name is an alias of
+// (registered through AddAlias or declared in config data file),
+// otherwise, it returns the passed command name as is
+// an error is returned if StrictCommands is set and the resolved command is unknown
+func (c *Comic) resolveCommandName(commandName string) (string, error) {
+	if name, ok := c.getAliasedCommandName(commandName); ok {
+		commandName = name
+	}
+
+	if c.StrictCommands && !c.isKnownCommand(commandName) {
+		return "", fmt.Errorf("command '%s' unknown", commandName)
+	}
+
+	return commandName, nil
+}
+
+// getAliasedCommandName checks if the passed alias belongs to a command
+// if so, it returns the command name and true, otherwise, it returns an empty string and false
+//
+// note: aliases registered through AddAlias take precedence over the ones declared in config data file
+func (c *Comic) getAliasedCommandName(alias string) (commandName string, ok bool) {
+	if commandName, ok = c.aliases[alias]; ok {
+		return
+	}
+
+	declaredAliases := c.vip.GetStringMapStringSlice(aliasesKey)
+
+	names := make([]string, 0, len(declaredAliases))
+	for name := range declaredAliases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, declaredAlias := range declaredAliases[name] {
+			if declaredAlias == alias {
+				return name, true
+			}
+		}
+	}
+
+	return
+}
+
+// isKnownCommand checks if the passed command name has a section in the required section of config data file,
+// has required config variables registered through Require, or has aliases
+func (c *Comic) isKnownCommand(commandName string) bool {
+	for _, name := range c.getCommandNames() {
+		if name == commandName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCommandNames returns the names of all commands that have a section in the required section of config data file,
+// have required config variables registered through Require, or have aliases
+func (c *Comic) getCommandNames() (commandNames []string) {
+	seen := make(map[string]bool)
+
+	add := func(commandName string) {
+		if commandName != "" && !seen[commandName] {
+			seen[commandName] = true
+			commandNames = append(commandNames, commandName)
+		}
+	}
+
+	for _, key := range c.vip.AllKeys() {
+		if keyParts := strings.Split(key, viperNestedKeySeparator); len(keyParts) > 1 && keyParts[0] == requiredKey {
+			add(keyParts[1])
+		}
+	}
+
+	for commandName := range c.requirements {
+		add(commandName)
+	}
+
+	for _, commandName := range c.aliases {
+		add(commandName)
+	}
+
+	for commandName := range c.vip.GetStringMapStringSlice(aliasesKey) {
+		add(commandName)
+	}
+
+	sort.Strings(commandNames)
+
+	return
+}
@@ -0,0 +1,184 @@
+package comic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAlias(t *testing.T) {
+	defer func(original *Comic) { c = original }(c)
+	c = &Comic{}
+
+	AddAlias("serve", "srv", "s")
+
+	assert.Equal(t, map[string]string{"srv": "serve", "s": "serve"}, c.aliases)
+}
+
+func TestComic_AddAlias(t *testing.T) {
+	c := &Comic{}
+
+	c.AddAlias("serve", "srv")
+	c.AddAlias("index", "idx")
+	c.AddAlias("run job", "rj")
+
+	assert.Equal(t, map[string]string{"srv": "serve", "idx": "index", "rj": "run job"}, c.aliases)
+}
+
+func TestComic_resolveCommandName(t *testing.T) {
+	cases := []struct {
+		comic          *Comic
+		commandName    string
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			comic: &Comic{
+				vip: &mockViper{},
+			},
+			commandName:    "serve",
+			expectedOutput: "serve",
+			expectedError:  nil,
+		},
+		{
+			comic: &Comic{
+				vip: &mockViper{},
+				aliases: map[string]string{
+					"srv": "serve",
+				},
+			},
+			commandName:    "srv",
+			expectedOutput: "serve",
+			expectedError:  nil,
+		},
+		{
+			comic: &Comic{
+				vip: &mockViper{
+					values: map[string]interface{}{
+						"aliases": map[string][]string{
+							"serve":   {"srv"},
+							"run job": {"rj", "job"},
+						},
+					},
+				},
+			},
+			commandName:    "job",
+			expectedOutput: "run job",
+			expectedError:  nil,
+		},
+		{
+			comic: &Comic{
+				vip: &mockViper{
+					values: map[string]interface{}{
+						"aliases": map[string][]string{
+							"serve": {"s"},
+						},
+					},
+				},
+				aliases: map[string]string{
+					"s": "schedule",
+				},
+			},
+			commandName:    "s",
+			expectedOutput: "schedule",
+			expectedError:  nil,
+		},
+		{
+			comic: &Comic{
+				Options: Options{
+					StrictCommands: true,
+				},
+				vip: &mockViper{},
+			},
+			commandName:    "serve",
+			expectedOutput: "",
+			expectedError:  errors.New("command 'serve' unknown"),
+		},
+		{
+			comic: &Comic{
+				Options: Options{
+					StrictCommands: true,
+				},
+				vip: &mockViper{
+					keys: map[string]bool{
+						"required.serve": false,
+					},
+				},
+				aliases: map[string]string{
+					"srv": "serve",
+				},
+			},
+			commandName:    "srv",
+			expectedOutput: "serve",
+			expectedError:  nil,
+		},
+		{
+			comic: &Comic{
+				Options: Options{
+					StrictCommands: true,
+				},
+				vip: &mockViper{
+					keys: map[string]bool{
+						"required.serve.port": false,
+					},
+				},
+			},
+			commandName:    "serve",
+			expectedOutput: "serve",
+			expectedError:  nil,
+		},
+		{
+			comic: &Comic{
+				Options: Options{
+					StrictCommands: true,
+				},
+				vip: &mockViper{
+					keys: map[string]bool{
+						"required.server.port": false,
+					},
+				},
+			},
+			commandName:    "serve",
+			expectedOutput: "",
+			expectedError:  errors.New("command 'serve' unknown"),
+		},
+	}
+
+	for _, c := range cases {
+		commandName, err := c.comic.resolveCommandName(c.commandName)
+
+		assert.Equal(t, c.expectedOutput, commandName)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_getCommandNames(t *testing.T) {
+	c := &Comic{
+		vip: &mockViper{
+			keys: map[string]bool{
+				"name":                  true,
+				"required":              false,
+				"required.api.name":     false,
+				"required.api.port":     false,
+				"required.run job.name": false,
+				"required.indexer":      false,
+			},
+			values: map[string]interface{}{
+				"aliases": map[string][]string{
+					"api":      {"srv"},
+					"schedule": {"s"},
+				},
+			},
+		},
+		aliases: map[string]string{
+			"idx": "indexer",
+			"w":   "watch",
+		},
+		requirements: map[string][]string{
+			"migrate": {"db.url"},
+		},
+	}
+
+	assert.Equal(t, []string{"api", "indexer", "migrate", "run job", "schedule", "watch"}, c.getCommandNames())
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// separator of the levels of nesting of config keys e.g. db.password
+const keySeparator = "."
+
+// transformFileValue replaces the value of the passed (case-insensitive) key in the passed config data file
+// with the result of passing it to the passed function, rewriting the file in place
+// YAML files keep their comments & key order, while JSON files get their keys sorted
+// the type of the new value is resolved from it (e.g. a decrypted 8080 is a number), while encrypted values are strings
+// an error is returned if the file can't be read or written, its type isn't YAML or JSON
+// or the key doesn't hold a scalar value
+func transformFileValue(file, key string, transform func(value string) (string, error)) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var rewrite func(content []byte, keys []string, transform func(value string) (string, error)) ([]byte, error)
+
+	switch configType := strings.TrimPrefix(filepath.Ext(file), "."); configType {
+	case "yaml", "yml":
+		rewrite = rewriteYAMLValue
+	case "json":
+		rewrite = rewriteJSONValue
+	default:
+		return fmt.Errorf("config type '%s' not supported: use a YAML or JSON file", configType)
+	}
+
+	content, err = rewrite(content, strings.Split(key, keySeparator), transform)
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+
+	return ioutil.WriteFile(file, content, info.Mode())
+}
+
+// rewriteYAMLValue returns the passed YAML document with the value of the passed (nested) keys transformed
+func rewriteYAMLValue(content []byte, keys []string, transform func(value string) (string, error)) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("key '%s' not found", strings.Join(keys, keySeparator))
+	}
+
+	node := document.Content[0]
+
+	for i, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("key '%s' not found", strings.Join(keys[:i+1], keySeparator))
+		}
+
+		var value *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if strings.EqualFold(node.Content[j].Value, key) {
+				value = node.Content[j+1]
+			}
+		}
+
+		if value == nil {
+			return nil, fmt.Errorf("key '%s' not found", strings.Join(keys[:i+1], keySeparator))
+		}
+
+		node = value
+	}
+
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		return nil, fmt.Errorf("value of '%s' not a scalar", strings.Join(keys, keySeparator))
+	}
+
+	result, err := transform(node.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tag is resolved again from the value, so that decrypted values get their original types e.g. 8080 => !!int
+	node.Value, node.Tag, node.Style = result, "", 0
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// rewriteJSONValue returns the passed JSON document with the value of the passed (nested) keys transformed
+func rewriteJSONValue(content []byte, keys []string, transform func(value string) (string, error)) ([]byte, error) {
+	var document interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	parent, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("key '%s' not found", keys[0])
+	}
+
+	for i, key := range keys {
+		name := ""
+		for k := range parent {
+			if strings.EqualFold(k, key) {
+				name = k
+			}
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("key '%s' not found", strings.Join(keys[:i+1], keySeparator))
+		}
+
+		if i < len(keys)-1 {
+			if parent, ok = parent[name].(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("key '%s' not found", strings.Join(keys[:i+2], keySeparator))
+			}
+
+			continue
+		}
+
+		var value string
+
+		switch v := parent[name].(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+		case bool:
+			value = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("value of '%s' not a scalar", strings.Join(keys, keySeparator))
+		}
+
+		result, err := transform(value)
+		if err != nil {
+			return nil, err
+		}
+
+		parent[name] = resolveJSONScalar(result)
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+// resolveJSONScalar returns the passed value as a JSON number or boolean, if it's one
+// so that decrypted values get their original types e.g. 8080 => json.Number
+// otherwise, it returns it as a string
+func resolveJSONScalar(value string) interface{} {
+	switch {
+	case value == "true" || value == "false":
+		return value == "true"
+	case json.Valid([]byte(value)) && strings.IndexAny(value, " \t\r\n\"{[n") < 0:
+		return json.Number(value)
+	}
+
+	return value
+}
@@ -0,0 +1,129 @@
+// Command comic encrypts & decrypts individual config values for use in config data files
+//
+// usage:
+//
+//	comic keygen
+//	comic encrypt [-key-file path | -key-env name] < plaintext
+//	comic encrypt [-key-file path | -key-env name] file key
+//	comic decrypt [-key-file path | -key-env name] [value]
+//	comic decrypt [-key-file path | -key-env name] file key
+//
+// the value is read from stdin, unless it's passed as an argument (which exposes plaintext in shell history
+// & process listings) or a config data file (YAML or JSON) & a key (e.g. db.password) are passed,
+// in which case the value of the key is replaced in the file
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/zaininfo/comic"
+)
+
+const usage = `usage:
+  comic keygen
+  comic encrypt [-key-file path | -key-env name] < plaintext
+  comic encrypt [-key-file path | -key-env name] file key
+  comic decrypt [-key-file path | -key-env name] [value]
+  comic decrypt [-key-file path | -key-env name] file key`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command described by the passed arguments
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if args[0] == "keygen" {
+		key, err := comic.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, key)
+
+		return err
+	}
+
+	var transform func(value, key string) (string, error)
+
+	switch args[0] {
+	case "encrypt":
+		transform = comic.Encrypt
+	case "decrypt":
+		transform = comic.Decrypt
+	default:
+		return errors.New(usage)
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	keyFile := flags.String("key-file", "", "path of the file containing the encryption key")
+	keyEnv := flags.String("key-env", "", "name of the environment variable containing the encryption key")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%s\n%s", err, usage)
+	}
+
+	key, err := comic.ReadEncryptionKey(*keyFile, *keyEnv)
+	if err != nil && *keyFile == "" {
+		return fmt.Errorf("%s: use -key-file or -key-env", err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(flags.Args()) == 2 {
+		return transformFileValue(flags.Arg(0), flags.Arg(1), func(value string) (string, error) {
+			if args[0] == "encrypt" && comic.IsEncrypted(value) {
+				return "", fmt.Errorf("value of '%s' already encrypted", flags.Arg(1))
+			}
+
+			return transform(value, key)
+		})
+	}
+
+	value, err := readValue(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+
+	result, err := transform(value, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, result)
+
+	return err
+}
+
+// readValue returns the value passed as an argument, if any
+// otherwise, the (trimmed) value read from stdin
+func readValue(args []string, stdin io.Reader) (string, error) {
+	switch len(args) {
+	case 0:
+		value, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(value), "\r\n"), nil
+	case 1:
+		return args[0], nil
+	default:
+		return "", errors.New(usage)
+	}
+}
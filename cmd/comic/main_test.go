@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var keyOut bytes.Buffer
+	assert.NoError(t, run([]string{"keygen"}, nil, &keyOut))
+
+	key := strings.TrimSpace(keyOut.String())
+
+	dir, err := ioutil.TempDir("", "comic")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "key")
+	assert.NoError(t, ioutil.WriteFile(keyFile, keyOut.Bytes(), 0600))
+
+	var encrypted bytes.Buffer
+	assert.NoError(t, run([]string{"encrypt", "-key-file", keyFile}, strings.NewReader("secret\n"), &encrypted))
+	assert.True(t, strings.HasPrefix(encrypted.String(), "ENC[aes256-gcm,"))
+
+	os.Setenv("COMIC_TEST_KEY", key)
+	defer os.Unsetenv("COMIC_TEST_KEY")
+
+	var decrypted bytes.Buffer
+	assert.NoError(t, run([]string{"decrypt", "-key-env", "COMIC_TEST_KEY"}, &encrypted, &decrypted))
+	assert.Equal(t, "secret\n", decrypted.String())
+}
+
+func TestRun_file(t *testing.T) {
+	var keyOut bytes.Buffer
+	assert.NoError(t, run([]string{"keygen"}, nil, &keyOut))
+
+	dir, err := ioutil.TempDir("", "comic")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "key")
+	assert.NoError(t, ioutil.WriteFile(keyFile, keyOut.Bytes(), 0600))
+
+	cases := []struct {
+		name      string
+		content   string
+		key       string
+		encrypted func(content string) string
+	}{
+		{
+			name: "config.yaml",
+			content: `# database
+db:
+  host: localhost # local only
+  port: 1234
+required:
+  main:
+    db:
+      port:
+`,
+			key: "DB.port",
+			encrypted: func(content string) string {
+				return strings.SplitN(strings.SplitN(content, "port: ", 2)[1], "\n", 2)[0]
+			},
+		},
+		{
+			name: "config.json",
+			content: `{
+  "db": {
+    "host": "localhost",
+    "port": 1234
+  }
+}
+`,
+			key: "db.port",
+			encrypted: func(content string) string {
+				return strings.SplitN(strings.SplitN(content, `"port": "`, 2)[1], `"`, 2)[0]
+			},
+		},
+	}
+
+	for _, c := range cases {
+		file := filepath.Join(dir, c.name)
+		assert.NoError(t, ioutil.WriteFile(file, []byte(c.content), 0644))
+
+		assert.NoError(t, run([]string{"encrypt", "-key-file", keyFile, file, c.key}, nil, ioutil.Discard))
+
+		content, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(c.encrypted(string(content)), "ENC[aes256-gcm,"))
+		assert.NotContains(t, string(content), "1234")
+
+		assert.EqualError(t, run([]string{"encrypt", "-key-file", keyFile, file, c.key}, nil, ioutil.Discard),
+			file+": value of '"+c.key+"' already encrypted")
+
+		assert.NoError(t, run([]string{"decrypt", "-key-file", keyFile, file, c.key}, nil, ioutil.Discard))
+
+		content, err = ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, c.content, string(content))
+	}
+
+	tomlFile := filepath.Join(dir, "config.toml")
+	assert.NoError(t, ioutil.WriteFile(tomlFile, []byte("name = 'app'"), 0644))
+	assert.EqualError(t, run([]string{"encrypt", "-key-file", keyFile, tomlFile, "name"}, nil, ioutil.Discard),
+		"config type 'toml' not supported: use a YAML or JSON file")
+
+	malformedFile := filepath.Join(dir, "malformed.yaml")
+	assert.NoError(t, ioutil.WriteFile(malformedFile, []byte("0: [:!00 \xef"), 0644))
+	assert.Error(t, run([]string{"encrypt", "-key-file", keyFile, malformedFile, "name"}, nil, ioutil.Discard))
+
+	yamlFile := filepath.Join(dir, "config.yaml")
+	assert.EqualError(t, run([]string{"encrypt", "-key-file", keyFile, yamlFile, "db.user"}, nil, ioutil.Discard),
+		yamlFile+": key 'db.user' not found")
+	assert.EqualError(t, run([]string{"encrypt", "-key-file", keyFile, yamlFile, "required.main.db.port"}, nil, ioutil.Discard),
+		yamlFile+": value of 'required.main.db.port' not a scalar")
+}
+
+func TestRun_errors(t *testing.T) {
+	cases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          nil,
+			expectedError: usage,
+		},
+		{
+			args:          []string{"rotate"},
+			expectedError: usage,
+		},
+		{
+			args:          []string{"encrypt", "-key", "k"},
+			expectedError: "flag provided but not defined: -key\n" + usage,
+		},
+		{
+			args:          []string{"encrypt", "secret"},
+			expectedError: "encryption key not configured: use -key-file or -key-env",
+		},
+		{
+			args:          []string{"decrypt", "-key-env", "COMIC_TEST_MISSING_KEY", "secret"},
+			expectedError: "encryption key not configured: use -key-file or -key-env",
+		},
+	}
+
+	for _, c := range cases {
+		assert.EqualError(t, run(c.args, strings.NewReader(""), ioutil.Discard), c.expectedError)
+	}
+}
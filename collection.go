@@ -0,0 +1,344 @@
+package comic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+const (
+	// separator of the elements of slice config variables in environment variables e.g. a,b,c
+	envVarListSeparator = ","
+	// maximum index of an element of a slice config variable set through an indexed environment variable
+	maxEnvVarIndex = 1024
+)
+
+// decodeHook returns the decode hook used to unmarshal config variables into config structs
+// i.e. strings are decoded into durations, JSON-encoded slices & maps, or comma-separated slices
+func decodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToJSONHookFunc(),
+		mapstructure.StringToSliceHookFunc(envVarListSeparator),
+	))
+}
+
+// stringToJSONHookFunc returns a decode hook that decodes JSON-encoded strings (e.g. ["a","b"] or {"a":"b"})
+// into slices & maps
+// other strings are left as they are
+func stringToJSONHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String {
+			return data, nil
+		}
+
+		switch to.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+		default:
+			return data, nil
+		}
+
+		if value, ok := decodeJSON(reflect.ValueOf(data).String()); ok {
+			return value, nil
+		}
+
+		return data, nil
+	}
+}
+
+// decodeJSON decodes the passed string if it's a JSON-encoded array or object
+// if so, it returns the decoded value and true, otherwise, it returns nil and false
+func decodeJSON(s string) (value interface{}, ok bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "{") {
+		return
+	}
+
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// overrideCollectionEnvVars overrides the slice & map config variables of the fields of the passed struct
+// with their values merged with their indexed (e.g. SERVERS_0_HOST) or keyed (e.g. LABELS_TEAM) environment variables
+func (c *Comic) overrideCollectionEnvVars(cfg interface{}) {
+	values, _ := c.getCollectionEnvVars(cfg)
+
+	for key, value := range values {
+		c.override(key, value)
+	}
+}
+
+// getCollectionEnvVars returns the values of the slice & map config variables of the fields of the passed struct
+// merged with their indexed or keyed environment variables (if any), by key
+// along with the names of the environment variables used
+func (c *Comic) getCollectionEnvVars(cfg interface{}) (values map[string]interface{}, names []string) {
+	values = make(map[string]interface{})
+
+	knownNames := make(map[string]bool)
+	for _, key := range c.getKnownKeys(cfg) {
+		knownNames[c.envVarName(key)] = true
+	}
+
+	for _, field := range getStructFields(cfg) {
+		var value interface{}
+		var fieldNames []string
+
+		switch field.typ.Kind() {
+		case reflect.Slice, reflect.Array:
+			value, fieldNames = c.getIndexedEnvVars(field, knownNames)
+		case reflect.Map:
+			value, fieldNames = c.getKeyedEnvVars(field, knownNames)
+		}
+
+		if len(fieldNames) > 0 {
+			values[field.key] = value
+			names = append(names, fieldNames...)
+		}
+	}
+
+	return
+}
+
+// getIndexedEnvVars returns the value of the passed slice field merged with its indexed environment variables
+// e.g. SERVERS_0_HOST (for a slice of structs) or ORIGINS_0 (for a slice of scalars)
+// along with the names of the environment variables used
+func (c *Comic) getIndexedEnvVars(field structField, knownNames map[string]bool) (value []interface{}, names []string) {
+	elemType := field.typ.Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	value = toList(c.vip.Get(field.key))
+
+	for index := range c.getEnvVarIndices(field.key, knownNames) {
+		elemKey := field.key + viperNestedKeySeparator + strconv.Itoa(index)
+
+		for len(value) <= index {
+			value = append(value, nil)
+		}
+
+		if isLeafType(elemType) {
+			if elemValue, ok := lookupEnvVar(c.envVarName(elemKey)); ok {
+				value[index] = elemValue
+				names = append(names, c.envVarName(elemKey))
+			}
+
+			continue
+		}
+
+		elem := toStringMap(value[index])
+
+		for _, elemField := range getTypeFields(elemType, "") {
+			name := c.envVarName(elemKey + viperNestedKeySeparator + elemField.key)
+
+			if elemValue, ok := lookupEnvVar(name); ok {
+				setNestedValue(elem, elemField.key, elemValue)
+				names = append(names, name)
+			}
+		}
+
+		value[index] = elem
+	}
+
+	return
+}
+
+// getEnvVarIndices returns the indices of the elements of the passed slice key that have (unknown) environment variables
+func (c *Comic) getEnvVarIndices(key string, knownNames map[string]bool) map[int]bool {
+	indices := make(map[int]bool)
+
+	for suffix := range getUnknownEnvVarSuffixes(c.envVarName(key)+c.EnvVarNestedKeySeparator, knownNames) {
+		digits := suffix[:len(suffix)-len(strings.TrimLeft(suffix, "0123456789"))]
+		if digits == "" {
+			continue
+		}
+
+		rest := suffix[len(digits):]
+		if rest != "" && !strings.HasPrefix(rest, c.EnvVarNestedKeySeparator) {
+			continue
+		}
+
+		if index, err := strconv.Atoi(digits); err == nil && index <= maxEnvVarIndex {
+			indices[index] = true
+		}
+	}
+
+	return indices
+}
+
+// getKeyedEnvVars returns the value of the passed map field merged with its keyed environment variables
+// e.g. LABELS_TEAM => labels[team]
+// along with the names of the environment variables used
+//
+// note: maps of structs can only be set through JSON-encoded environment variables
+func (c *Comic) getKeyedEnvVars(field structField, knownNames map[string]bool) (value map[string]interface{}, names []string) {
+	elemType := field.typ.Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if !isLeafType(elemType) {
+		return
+	}
+
+	prefix := c.envVarName(field.key) + c.EnvVarNestedKeySeparator
+	value = toStringMap(c.vip.Get(field.key))
+
+	for suffix, entryValue := range getUnknownEnvVarSuffixes(prefix, knownNames) {
+		value[strings.ToLower(suffix)] = entryValue
+		names = append(names, prefix+suffix)
+	}
+
+	return
+}
+
+// getUnknownEnvVarSuffixes returns the values of all (non-empty) environment variables with the passed prefix
+// that aren't among the passed known names, by the rest of their names
+func getUnknownEnvVarSuffixes(prefix string, knownNames map[string]bool) map[string]string {
+	values := make(map[string]string)
+
+	for _, env := range os.Environ() {
+		nameValue := strings.SplitN(env, "=", 2)
+		if len(nameValue) != 2 || nameValue[1] == "" || knownNames[nameValue[0]] {
+			continue
+		}
+
+		if suffix := strings.TrimPrefix(nameValue[0], prefix); suffix != nameValue[0] && suffix != "" {
+			values[suffix] = nameValue[1]
+		}
+	}
+
+	return values
+}
+
+// isSet checks if the config variable of the passed key has a value
+// keys with indices of slice elements are supported as well e.g. servers.0.host
+func (c *Comic) isSet(key string) bool {
+	if c.vip.IsSet(key) {
+		return true
+	}
+
+	keyParts := strings.Split(key, viperNestedKeySeparator)
+
+	for i := 1; i < len(keyParts); i++ {
+		if _, err := strconv.Atoi(keyParts[i]); err == nil {
+			return lookupPath(c.vip.Get(strings.Join(keyParts[:i], viperNestedKeySeparator)), keyParts[i:]) != nil
+		}
+	}
+
+	return false
+}
+
+// lookupPath returns the value at the passed path (of slice indices & map keys) within the passed value, if any
+func lookupPath(value interface{}, path []string) interface{} {
+	for _, part := range path {
+		v := reflect.ValueOf(value)
+
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= v.Len() {
+				return nil
+			}
+
+			value = v.Index(index).Interface()
+		case reflect.Map:
+			value = nil
+
+			for _, mapKey := range v.MapKeys() {
+				if strings.EqualFold(fmt.Sprint(mapKey.Interface()), part) {
+					value = v.MapIndex(mapKey).Interface()
+					break
+				}
+			}
+		default:
+			return nil
+		}
+
+		if value == nil {
+			return nil
+		}
+	}
+
+	return value
+}
+
+// toList returns the passed value as a (new) slice
+// strings are decoded as JSON-encoded arrays or split by commas
+func toList(value interface{}) (list []interface{}) {
+	if s, ok := value.(string); ok {
+		if decoded, ok := decodeJSON(s); ok {
+			value = decoded
+		} else {
+			for _, elem := range strings.Split(s, envVarListSeparator) {
+				list = append(list, elem)
+			}
+
+			return
+		}
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		list = append(list, v.Index(i).Interface())
+	}
+
+	return
+}
+
+// toStringMap returns the passed value as a (new, deeply copied) map with string keys
+// strings are decoded as JSON-encoded objects
+func toStringMap(value interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+
+	if s, ok := value.(string); ok {
+		value, _ = decodeJSON(s)
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map {
+		return m
+	}
+
+	for _, mapKey := range v.MapKeys() {
+		mapValue := v.MapIndex(mapKey).Interface()
+		if reflect.ValueOf(mapValue).Kind() == reflect.Map {
+			mapValue = toStringMap(mapValue)
+		}
+
+		m[strings.ToLower(fmt.Sprint(mapKey.Interface()))] = mapValue
+	}
+
+	return m
+}
+
+// setNestedValue sets the passed value at the passed (nested) key within the passed map
+// creating intermediate maps as needed e.g. server.port => m[server][port]
+func setNestedValue(m map[string]interface{}, key string, value interface{}) {
+	keyParts := strings.Split(key, viperNestedKeySeparator)
+
+	for _, part := range keyParts[:len(keyParts)-1] {
+		nested, ok := m[part].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			m[part] = nested
+		}
+
+		m = nested
+	}
+
+	m[keyParts[len(keyParts)-1]] = value
+}
@@ -0,0 +1,264 @@
+package comic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type collectionServer struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+}
+
+type collectionConfig struct {
+	Servers        []collectionServer `mapstructure:"servers"`
+	AllowedOrigins []string           `mapstructure:"allowed_origins"`
+	Ports          []int              `mapstructure:"ports"`
+	Labels         map[string]string  `mapstructure:"labels"`
+	Limits         map[string]int     `mapstructure:"limits"`
+}
+
+func TestComic_LoadForCommand_collectionEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+servers:
+  - host: a
+    port: 1
+labels:
+  env: dev
+required:
+  main:
+    servers:
+      0:
+        host:
+      1:
+        host:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"SERVERS_0_PORT":  "8080",
+		"SERVERS_1_HOST":  "b",
+		"ALLOWED_ORIGINS": "x,y,z",
+		"PORTS":           "[80, 443]",
+		"LABELS_TEAM":     "core",
+		"LIMITS":          `{"cpu": 2}`,
+	})
+	defer unset()
+
+	expected := &collectionConfig{
+		Servers: []collectionServer{
+			{Host: "a", Port: 8080},
+			{Host: "b"},
+		},
+		AllowedOrigins: []string{"x", "y", "z"},
+		Ports:          []int{80, 443},
+		Labels:         map[string]string{"env": "dev", "team": "core"},
+		Limits:         map[string]int{"cpu": 2},
+	}
+
+	cfg := &collectionConfig{}
+	assert.NoError(t, NewWithOptions(Options{ConfigFilePath: dir}).Load(cfg))
+	assert.Equal(t, expected, cfg)
+
+	unset()
+
+	err := NewWithOptions(Options{ConfigFilePath: dir}).Load(&collectionConfig{})
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: servers.1.host"), err)
+}
+
+func TestComic_checkUnknownEnvVars_collections(t *testing.T) {
+	unset := setEnvVars(map[string]string{
+		"MYAPP_SERVERS_0_HOST": "a",
+		"MYAPP_SERVERS_1_PORT": "1",
+		"MYAPP_LABELS_TEAM":    "core",
+		"MYAPP_PORTS_0":        "80",
+		"MYAPP_SERVERS_X_HOST": "b",
+	})
+	defer unset()
+
+	c := NewWithOptions(Options{EnvPrefix: "myapp", StrictEnvVars: true})
+	c.vip = &mockViper{}
+
+	err := c.checkUnknownEnvVars(&collectionConfig{}, "main")
+	assert.Equal(t, errors.New("env vars unknown: MYAPP_SERVERS_X_HOST (did you mean MYAPP_SERVERS_0_HOST?)"), err)
+}
+
+func TestComic_isSet(t *testing.T) {
+	c := &Comic{
+		vip: &mockViper{
+			keys: map[string]bool{
+				"name": true,
+			},
+			values: map[string]interface{}{
+				"servers": []interface{}{
+					map[interface{}]interface{}{"host": "a"},
+					map[string]interface{}{"port": 1},
+				},
+				"ports": []int{80},
+			},
+		},
+	}
+
+	cases := []struct {
+		key      string
+		expected bool
+	}{
+		{
+			key:      "name",
+			expected: true,
+		},
+		{
+			key:      "ttl",
+			expected: false,
+		},
+		{
+			key:      "servers.0.host",
+			expected: true,
+		},
+		{
+			key:      "servers.0.port",
+			expected: false,
+		},
+		{
+			key:      "servers.1.port",
+			expected: true,
+		},
+		{
+			key:      "servers.2.host",
+			expected: false,
+		},
+		{
+			key:      "ports.0",
+			expected: true,
+		},
+		{
+			key:      "ports.1",
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, c.isSet(tc.key), tc.key)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	cases := []struct {
+		s              string
+		expectedOutput interface{}
+		expectedStatus bool
+	}{
+		{
+			s:              "a,b",
+			expectedOutput: nil,
+			expectedStatus: false,
+		},
+		{
+			s:              `["a", "b"]`,
+			expectedOutput: []interface{}{"a", "b"},
+			expectedStatus: true,
+		},
+		{
+			s:              ` {"a": 1}`,
+			expectedOutput: map[string]interface{}{"a": float64(1)},
+			expectedStatus: true,
+		},
+		{
+			s:              "[a, b]",
+			expectedOutput: nil,
+			expectedStatus: false,
+		},
+	}
+
+	for _, c := range cases {
+		value, ok := decodeJSON(c.s)
+
+		assert.Equal(t, c.expectedOutput, value)
+		assert.Equal(t, c.expectedStatus, ok)
+	}
+}
+
+func TestToList(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected []interface{}
+	}{
+		{
+			value:    nil,
+			expected: nil,
+		},
+		{
+			value:    "a,b",
+			expected: []interface{}{"a", "b"},
+		},
+		{
+			value:    `["a"]`,
+			expected: []interface{}{"a"},
+		},
+		{
+			value:    []int{1, 2},
+			expected: []interface{}{1, 2},
+		},
+		{
+			value:    map[string]interface{}{},
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, toList(c.value))
+	}
+}
+
+func TestToStringMap(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected map[string]interface{}
+	}{
+		{
+			value:    nil,
+			expected: map[string]interface{}{},
+		},
+		{
+			value:    `{"A": "b"}`,
+			expected: map[string]interface{}{"a": "b"},
+		},
+		{
+			value: map[interface{}]interface{}{
+				"host":   "a",
+				"nested": map[interface{}]interface{}{1: "b"},
+			},
+			expected: map[string]interface{}{
+				"host":   "a",
+				"nested": map[string]interface{}{"1": "b"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, toStringMap(c.value))
+	}
+}
+
+func TestSetNestedValue(t *testing.T) {
+	m := map[string]interface{}{
+		"server": map[string]interface{}{"host": "a"},
+		"name":   "app",
+	}
+
+	setNestedValue(m, "server.port", 1)
+	setNestedValue(m, "name.first", "x")
+	setNestedValue(m, "ttl", "1s")
+
+	expected := map[string]interface{}{
+		"server": map[string]interface{}{"host": "a", "port": 1},
+		"name":   map[string]interface{}{"first": "x"},
+		"ttl":    "1s",
+	}
+
+	assert.Equal(t, expected, m)
+}
@@ -3,7 +3,11 @@ package comic
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/spf13/viper"
 )
@@ -21,6 +25,10 @@ const (
 	viperNestedKeySeparator = "."
 	// pattern of the path of keys used to set required config variables in config data file
 	commandKeyPattern = "required.%s."
+	// key of the section used to declare required config variables in config data file
+	requiredKey = "required"
+	// key of the section used to declare command aliases in config data file
+	aliasesKey = "aliases"
 	// separator of nested command names
 	commandNameSeparator = " "
 )
@@ -34,12 +42,89 @@ func init() {
 // Comic contains all relevant info of a Comic instance
 type Comic struct {
 	Options
-	vip comicViper
+	vip            comicViper
+	aliases        map[string]string
+	resolvers      map[string]Resolver
+	requirements   map[string][]string
+	overriddenKeys []string
+	configFiles    []string
+	remoteMutex    sync.Mutex
+	remoteContent  []byte
+	remoteETag     string
 }
 
 // Options contains all configurable options of Comic
 type Options struct {
 	ConfigFileName, ConfigFilePath, SingleCommandAppName, EnvVarNestedKeySeparator string
+	// ConfigFile & ConfigFileEnvVar are the full path of config data file (e.g. /etc/myapp/prod.yml) & the name of
+	// the environment variable containing it (the former takes precedence), used instead of searching for it if set
+	ConfigFile, ConfigFileEnvVar string
+	// ConfigFilePaths are the paths config data file is searched in, in order (e.g. DefaultSearchPaths("myapp")),
+	// used instead of ConfigFilePath if set
+	ConfigFilePaths []string
+	// MergeConfigFiles makes the config data files found in all of ConfigFilePaths get merged
+	// (the ones found in earlier paths taking precedence), instead of only the first one found being loaded
+	MergeConfigFiles bool
+	// ConfigFileOptional makes a missing config data file be treated as an empty one, instead of an error
+	// (e.g. for deployments configured through environment variables only)
+	//
+	// note: malformed config data files are still an error
+	ConfigFileOptional bool
+	// DefaultConfigFS & DefaultConfigFile are the file system (e.g. an embed.FS) & the path within it of
+	// a default config data file, loaded beneath config data file (ConfigFileName.<ext> is looked for if the path is empty)
+	//
+	// note: config data file is optional if a default one is set
+	DefaultConfigFS   fs.FS
+	DefaultConfigFile string
+	// RemoteConfigURL is the URL of a remote config document (e.g. https://config.example.com/myapp.yaml),
+	// fetched while loading config & layered on top of config data file (which is then optional)
+	// RemoteConfigFormat is its format (e.g. yaml), inferred from the extension of the URL if empty
+	// RemoteConfigCacheFile is the path of the file a copy of it is cached in, used if the URL can't be reached
+	// RemoteConfigClient is the HTTP client used to fetch it (a client with a timeout of 10 seconds, if nil)
+	RemoteConfigURL, RemoteConfigFormat, RemoteConfigCacheFile string
+	RemoteConfigClient                                         *http.Client
+	// ConfigDirs are the paths of directories containing one file per config variable (e.g. mounted Kubernetes
+	// ConfigMaps & Secrets), where the relative path of each file is the key (by dots and/or subdirectories
+	// e.g. server.port or server/port) & its (trimmed) content is the value, layered on top of config data file
+	// (in order) & beneath the environment
+	//
+	// note: hidden files (including the ..data layout of Kubernetes) are skipped & absent directories are ignored
+	ConfigDirs []string
+	// EnvFiles are the paths of .env files (e.g. .env) containing environment variables, which take effect unless
+	// they're set in the environment (later files taking precedence over earlier ones)
+	//
+	// note: only the environment variables of known & required config variables are used & absent files are ignored
+	EnvFiles []string
+	// EnvPrefix is prepended (followed by an underscore) to the names of all environment variables
+	EnvPrefix string
+	// StrictCommands makes loading config for a command without a required section (or alias) an error
+	StrictCommands bool
+	// StrictKeys makes config variables that don't map to any field of the config struct an error
+	StrictKeys bool
+	// StrictEnvVars makes environment variables with EnvPrefix that don't map to any config variable an error
+	StrictEnvVars bool
+	// StrictEnvVarNames makes config variables sharing the same environment variable name an error
+	StrictEnvVarNames bool
+	// CommandEnvVars makes environment variables scoped by command name (e.g. API_SERVER_PORT for api command)
+	// take precedence over unscoped ones while loading config for the command
+	CommandEnvVars bool
+	// FileEnvVars makes environment variables suffixed with _FILE (e.g. DB_PASSWORD_FILE) set config variables
+	// to the (trimmed) contents of the files they point to
+	FileEnvVars bool
+	// EncryptionKeyFile & EncryptionKeyEnvVar are the path of the file & the name of the environment variable
+	// containing the (base64-encoded) key to decrypt encrypted config values with (the latter takes precedence)
+	EncryptionKeyFile, EncryptionKeyEnvVar string
+	// InterpolateValues makes references within config values (e.g. ${DB_HOST}, ${DB_HOST:-localhost} or ${db.host})
+	// be replaced with the values of the environment or config variables they refer to
+	InterpolateValues bool
+	// Profile is the name of the overlay of config data file loaded on top of it
+	// (e.g. production for config.production.yaml), read from the environment variable named ProfileEnvVar if empty
+	//
+	// note: the config.local.yaml overlay (if present) is loaded on top of both
+	Profile, ProfileEnvVar string
+	// SliceMerge is the strategy for merging slices of overlays into the ones of config data file
+	// i.e. SliceMergeReplace or SliceMergeAppend
+	SliceMerge string
 }
 
 // New creates a new instance of Comic with it's own instance of Viper and default options
@@ -67,6 +152,14 @@ func NewWithOptions(opts Options) *Comic {
 		opts.EnvVarNestedKeySeparator = defOpts.EnvVarNestedKeySeparator
 	}
 
+	if opts.ProfileEnvVar == "" {
+		opts.ProfileEnvVar = defOpts.ProfileEnvVar
+	}
+
+	if opts.SliceMerge == "" {
+		opts.SliceMerge = defOpts.SliceMerge
+	}
+
 	return &Comic{
 		Options: opts,
 		vip:     viper.New(),
@@ -80,6 +173,8 @@ func defaultOptions() Options {
 		ConfigFilePath:           defaultConfigFilePath,
 		SingleCommandAppName:     defaultSingleCommandAppName,
 		EnvVarNestedKeySeparator: defaultEnvVarNestedKeySeparator,
+		ProfileEnvVar:            defaultProfileEnvVar,
+		SliceMerge:               defaultSliceMerge,
 	}
 }
 
@@ -142,25 +237,86 @@ func LoadForCommand(cfg interface{}, commandName string) error {
 	return c.LoadForCommand(cfg, commandName)
 }
 func (c *Comic) LoadForCommand(cfg interface{}, commandName string) error {
+	return c.load(cfg, commandName, c.readInConfig)
+}
+
+// LoadFromReader:
+// - reads config data of the passed format (e.g. yaml) from the passed reader, instead of config data file
+// - verifies the required config variables of the passed command
+// - loads all config variables into the passed struct
+// an error is returned in case of a failure
+//
+// note: cfg *must* be a pointer
+// note: config data in a byte slice can be read through bytes.NewReader
+func LoadFromReader(cfg interface{}, r io.Reader, format, commandName string) error {
+	return c.LoadFromReader(cfg, r, format, commandName)
+}
+func (c *Comic) LoadFromReader(cfg interface{}, r io.Reader, format, commandName string) error {
+	return c.load(cfg, commandName, func() error { return c.readConfig(r, format) })
+}
+
+// load reads config data through the passed function, merges it with the environment,
+// verifies the required config variables of the passed command and loads all config variables into the passed struct
+func (c *Comic) load(cfg interface{}, commandName string, readConfig func() error) error {
 	if commandName == "" {
 		return errors.New("command name empty")
 	}
 
-	c.vip.SetConfigName(c.ConfigFileName)
-	c.vip.AddConfigPath(c.ConfigFilePath)
-
+	c.vip.SetEnvPrefix(c.EnvPrefix)
 	c.vip.AutomaticEnv()
 	c.vip.SetEnvKeyReplacer(strings.NewReplacer(viperNestedKeySeparator, c.EnvVarNestedKeySeparator))
 
-	if err := c.vip.ReadInConfig(); err != nil {
+	if err := c.bindEnvVars(cfg); err != nil {
+		return fmt.Errorf("env not bound: %s", err)
+	}
+
+	if err := readConfig(); err != nil {
 		return fmt.Errorf("config not loaded: %s", err)
 	}
 
-	if err := c.checkRequiredVars(commandName); err != nil {
+	c.resetOverrides()
+
+	commandName, err := c.resolveCommandName(commandName)
+	if err != nil {
+		return err
+	}
+
+	if err := c.checkUnknownEnvVars(cfg, commandName); err != nil {
+		return err
+	}
+
+	if err := c.checkEnvVarCollisions(cfg); err != nil {
+		return err
+	}
+
+	if err := c.overrideEnvFileVars(cfg, commandName); err != nil {
+		return fmt.Errorf("env file not read: %s", err)
+	}
+
+	if err := c.overrideFileEnvVars(cfg, commandName); err != nil {
+		return fmt.Errorf("env not loaded: %s", err)
+	}
+
+	c.overrideCommandEnvVars(cfg, commandName)
+	c.overrideCollectionEnvVars(cfg)
+
+	if err := c.decryptValues(); err != nil {
+		return err
+	}
+
+	if err := c.resolveRefs(); err != nil {
+		return err
+	}
+
+	if err := c.interpolateValues(); err != nil {
+		return err
+	}
+
+	if err := c.checkRequiredVars(cfg, commandName); err != nil {
 		return fmt.Errorf("required config for command '%s' missing: %s", commandName, err)
 	}
 
-	if err := c.vip.Unmarshal(cfg); err != nil {
+	if err := c.unmarshal(cfg); err != nil {
 		return fmt.Errorf("config not parsed: %s", err)
 	}
 
@@ -168,18 +324,26 @@ func (c *Comic) LoadForCommand(cfg interface{}, commandName string) error {
 }
 
 // checkRequiredVars verifies that all required config variables are present (i.e. have values)
-// for the passed command name
-func (c *Comic) checkRequiredVars(commandName string) error {
-	for _, varName := range c.getRequiredVarNames(commandName) {
-		if !c.vip.IsSet(varName) {
-			return fmt.Errorf("config not present: %s", varName)
+// for the passed command name, along with the ones of the fields of the passed struct tagged as required
+// the name of the environment variable of a missing config variable is included in the error if EnvPrefix is set
+func (c *Comic) checkRequiredVars(cfg interface{}, commandName string) error {
+	for _, varName := range append(c.getRequiredVarNames(commandName), getRequiredStructKeys(cfg)...) {
+		if c.isSet(varName) {
+			continue
+		}
+
+		if c.EnvPrefix != "" {
+			return fmt.Errorf("config not present: %s (env var %s)", varName, c.envVarName(varName))
 		}
+
+		return fmt.Errorf("config not present: %s", varName)
 	}
 
 	return nil
 }
 
 // getRequiredVarNames returns the key names of all the required config variables of the passed command name
+// declared in config data file, followed by the ones registered through Require
 func (c *Comic) getRequiredVarNames(commandName string) (requiredVarNames []string) {
 	for _, key := range c.vip.AllKeys() {
 		if requiredKeyName, ok := getRequiredKeyName(key, commandName); ok {
@@ -187,7 +351,7 @@ func (c *Comic) getRequiredVarNames(commandName string) (requiredVarNames []stri
 		}
 	}
 
-	return
+	return append(requiredVarNames, c.requirements[commandName]...)
 }
 
 // getRequiredKeyName checks if the passed key is a requirement key of the passed command name
@@ -206,3 +370,19 @@ func getRequiredKeyName(key, commandName string) (requiredKeyName string, ok boo
 
 	return
 }
+
+// override sets the passed value of the passed key above all other sources (i.e. environment & config data file)
+// until the next time config is loaded
+func (c *Comic) override(key string, value interface{}) {
+	c.vip.Set(key, value)
+	c.overriddenKeys = append(c.overriddenKeys, key)
+}
+
+// resetOverrides removes all values set through override, since the last time config was loaded
+func (c *Comic) resetOverrides() {
+	for _, key := range c.overriddenKeys {
+		c.vip.Set(key, nil)
+	}
+
+	c.overriddenKeys = nil
+}
@@ -2,6 +2,10 @@ package comic
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -15,6 +19,8 @@ func TestNew(t *testing.T) {
 			ConfigFilePath:           ".",
 			SingleCommandAppName:     "main",
 			EnvVarNestedKeySeparator: "_",
+			ProfileEnvVar:            "COMIC_PROFILE",
+			SliceMerge:               "replace",
 		},
 		vip: viper.New(),
 	}
@@ -35,6 +41,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           ".",
 					SingleCommandAppName:     "main",
 					EnvVarNestedKeySeparator: "_",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -49,6 +57,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           ".",
 					SingleCommandAppName:     "main",
 					EnvVarNestedKeySeparator: "_",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -63,6 +73,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           "..",
 					SingleCommandAppName:     "main",
 					EnvVarNestedKeySeparator: "_",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -77,6 +89,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           ".",
 					SingleCommandAppName:     "app",
 					EnvVarNestedKeySeparator: "_",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -91,6 +105,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           ".",
 					SingleCommandAppName:     "main",
 					EnvVarNestedKeySeparator: "::",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -106,6 +122,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           "..",
 					SingleCommandAppName:     "main",
 					EnvVarNestedKeySeparator: "_",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -123,6 +141,8 @@ func TestNewWithOptions(t *testing.T) {
 					ConfigFilePath:           "..",
 					SingleCommandAppName:     "app",
 					EnvVarNestedKeySeparator: "::",
+					ProfileEnvVar:            "COMIC_PROFILE",
+					SliceMerge:               "replace",
 				},
 				vip: viper.New(),
 			},
@@ -140,6 +160,8 @@ func TestDefaultOptions(t *testing.T) {
 		ConfigFilePath:           ".",
 		SingleCommandAppName:     "main",
 		EnvVarNestedKeySeparator: "_",
+		ProfileEnvVar:            "COMIC_PROFILE",
+		SliceMerge:               "replace",
 	}
 
 	assert.Equal(t, expected, defaultOptions())
@@ -326,6 +348,46 @@ func loadTestCases() []struct {
 			},
 			expectedError: nil,
 		},
+		{
+			comic: &Comic{
+				vip: &mockViper{
+					cfg: &sampleConfig{
+						name: "app",
+					},
+					keys: map[string]bool{
+						"name":              false,
+						"required.run.name": false,
+					},
+				},
+				aliases: map[string]string{
+					"r": "run",
+				},
+			},
+			cfg:            &sampleConfig{},
+			cmd:            "r",
+			expectedOutput: &sampleConfig{},
+			expectedError:  errors.New("required config for command 'run' missing: config not present: name"),
+		},
+		{
+			comic: &Comic{
+				Options: Options{
+					StrictCommands: true,
+				},
+				vip: &mockViper{
+					cfg: &sampleConfig{
+						name: "app",
+					},
+					keys: map[string]bool{
+						"name":              true,
+						"required.run.name": false,
+					},
+				},
+			},
+			cfg:            &sampleConfig{},
+			cmd:            "schedule",
+			expectedOutput: &sampleConfig{},
+			expectedError:  errors.New("command 'schedule' unknown"),
+		},
 	}
 }
 
@@ -339,6 +401,29 @@ func runAndRecover(f func()) (panic interface{}) {
 	return
 }
 
+// writeConfigFiles writes the passed config data files (content by name) into a new temporary directory
+// and returns the path of the directory along with a function to remove it
+func writeConfigFiles(files map[string]string) (dir string, remove func()) {
+	dir, err := ioutil.TempDir("", "comic")
+	if err != nil {
+		panic(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			panic(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			panic(err)
+		}
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
 func TestMustLoad(t *testing.T) {
 	for _, tc := range loadTestCases() {
 		tc.comic.SingleCommandAppName = tc.cmd
@@ -433,6 +518,80 @@ func TestComic_LoadForCommand(t *testing.T) {
 	}
 }
 
+func TestLoadFromReader(t *testing.T) {
+	defer func(original *Comic) { c = original }(c)
+
+	for _, tc := range loadTestCases() {
+		c = tc.comic
+
+		err := LoadFromReader(tc.cfg, strings.NewReader(""), "yaml", tc.cmd)
+
+		assert.Equal(t, tc.expectedOutput, tc.cfg)
+		assert.Equal(t, tc.expectedError, err)
+	}
+}
+
+func TestComic_LoadFromReader(t *testing.T) {
+	for _, c := range loadTestCases() {
+		err := c.comic.LoadFromReader(c.cfg, strings.NewReader(""), "yaml", c.cmd)
+
+		assert.Equal(t, c.expectedOutput, c.cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadFromReader_formats(t *testing.T) {
+	unset := setEnvVars(map[string]string{
+		"SERVER_PORT": "8080",
+	})
+	defer unset()
+
+	cases := []struct {
+		config, format string
+		expectedOutput *strictConfig
+		expectedError  error
+	}{
+		{
+			config: `
+name: app
+server:
+  host: localhost
+required:
+  main:
+    server.port:
+`,
+			format: "yaml",
+			expectedOutput: &strictConfig{
+				Name: "app",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 8080},
+			},
+		},
+		{
+			config:         `{"required": {"main": {"name": null}}}`,
+			format:         "json",
+			expectedOutput: &strictConfig{},
+			expectedError:  errors.New("required config for command 'main' missing: config not present: name"),
+		},
+		{
+			config:         "name = app",
+			format:         "xml",
+			expectedOutput: &strictConfig{},
+			expectedError:  errors.New("config not loaded: config type 'xml' not supported"),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &strictConfig{}
+		err := NewWithOptions(Options{ConfigFilePath: "/nonexistent"}).LoadFromReader(cfg, strings.NewReader(c.config), c.format, "main")
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
 func TestComic_checkRequiredVars(t *testing.T) {
 	cases := []struct {
 		comic         *Comic
@@ -641,10 +800,28 @@ func TestComic_checkRequiredVars(t *testing.T) {
 			commandName:   "run job",
 			expectedError: errors.New("config not present: name"),
 		},
+		{
+			comic: &Comic{
+				Options: Options{
+					EnvVarNestedKeySeparator: "_",
+					EnvPrefix:                "myapp",
+				},
+				vip: &mockViper{
+					keys: map[string]bool{
+						"name":                     true,
+						"server.port":              false,
+						"required.run.name":        false,
+						"required.run.server.port": false,
+					},
+				},
+			},
+			commandName:   "run",
+			expectedError: errors.New("config not present: server.port (env var MYAPP_SERVER_PORT)"),
+		},
 	}
 
 	for _, c := range cases {
-		assert.Equal(t, c.expectedError, c.comic.checkRequiredVars(c.commandName))
+		assert.Equal(t, c.expectedError, c.comic.checkRequiredVars(nil, c.commandName))
 	}
 }
 
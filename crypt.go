@@ -0,0 +1,175 @@
+package comic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const (
+	// prefix of encrypted config values e.g. ENC[aes256-gcm,...]
+	encryptedValuePrefix = "ENC["
+	// suffix of encrypted config values
+	encryptedValueSuffix = "]"
+	// algorithm used to encrypt config values
+	encryptionAlgorithm = "aes256-gcm"
+	// size of encryption keys in bytes
+	encryptionKeySize = 32
+)
+
+// GenerateKey returns a new random (base64-encoded) key to encrypt config values with
+func GenerateKey() (string, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt encrypts the passed value with the passed (base64-encoded) key
+// into the form used in config data file i.e. ENC[aes256-gcm,<base64-encoded nonce & ciphertext>]
+func Encrypt(value, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+
+	return encryptedValuePrefix + encryptionAlgorithm + "," + base64.StdEncoding.EncodeToString(sealed) + encryptedValueSuffix, nil
+}
+
+// Decrypt decrypts the passed value, in the form used in config data file, with the passed (base64-encoded) key
+func Decrypt(value, key string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value not encrypted")
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(value, encryptedValuePrefix), encryptedValueSuffix), ",", 2)
+	if len(parts) != 2 || parts[0] != encryptionAlgorithm {
+		return "", fmt.Errorf("encryption algorithm not supported: %s", parts[0])
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("encrypted value not decoded: %s", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value too short")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+// IsEncrypted checks if the passed value is in the form of encrypted config values i.e. ENC[...]
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix) && strings.HasSuffix(value, encryptedValueSuffix)
+}
+
+// newGCM returns the AES-GCM cipher of the passed (base64-encoded) key
+func newGCM(key string) (cipher.AEAD, error) {
+	rawKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key not decoded: %s", err)
+	}
+
+	if len(rawKey) != encryptionKeySize {
+		return nil, fmt.Errorf("encryption key not %d bytes long", encryptionKeySize)
+	}
+
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decryptValues overrides all encrypted config variables (excluding the sections used by Comic itself)
+// with their decrypted values
+// an error is returned if a value can't be decrypted, or if there are encrypted values but no encryption key
+func (c *Comic) decryptValues() error {
+	keys := c.vip.AllKeys()
+	sort.Strings(keys)
+
+	var encryptionKey string
+
+	for _, key := range keys {
+		if isReservedKey(key) {
+			continue
+		}
+
+		value, ok := c.vip.Get(key).(string)
+		if !ok || !IsEncrypted(value) {
+			continue
+		}
+
+		if encryptionKey == "" {
+			var err error
+			if encryptionKey, err = c.getEncryptionKey(); err != nil {
+				return err
+			}
+		}
+
+		decrypted, err := Decrypt(value, encryptionKey)
+		if err != nil {
+			return fmt.Errorf("config '%s' not decrypted: %s", key, err)
+		}
+
+		c.override(key, decrypted)
+	}
+
+	return nil
+}
+
+// getEncryptionKey returns the encryption key from the environment variable named EncryptionKeyEnvVar, if set
+// otherwise, from the file at EncryptionKeyFile
+func (c *Comic) getEncryptionKey() (string, error) {
+	return ReadEncryptionKey(c.EncryptionKeyFile, c.EncryptionKeyEnvVar)
+}
+
+// ReadEncryptionKey returns the (base64-encoded) encryption key from the passed environment variable, if set
+// otherwise, from the passed file
+// an error is returned if neither is set or the file can't be read
+func ReadEncryptionKey(keyFile, keyEnvVar string) (string, error) {
+	if keyEnvVar != "" {
+		if key, ok := lookupEnvVar(keyEnvVar); ok {
+			return key, nil
+		}
+	}
+
+	if keyFile == "" {
+		return "", errors.New("encryption key not configured")
+	}
+
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("encryption key not read: %s", err)
+	}
+
+	return string(key), nil
+}
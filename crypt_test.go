@@ -0,0 +1,255 @@
+package comic
+
+import (
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testEncryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	rawKey, err := base64.StdEncoding.DecodeString(key)
+	assert.NoError(t, err)
+	assert.Len(t, rawKey, 32)
+
+	otherKey, err := GenerateKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, value := range []string{"", "secret", "pässwörd with spaces"} {
+		encrypted, err := Encrypt(value, testEncryptionKey)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, "ENC[aes256-gcm,"))
+		assert.True(t, IsEncrypted(encrypted))
+
+		decrypted, err := Decrypt(encrypted, testEncryptionKey)
+		assert.NoError(t, err)
+		assert.Equal(t, value, decrypted)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	cases := []struct {
+		key           string
+		expectedError error
+	}{
+		{
+			key:           "not base64!",
+			expectedError: errors.New("encryption key not decoded: illegal base64 data at input byte 3"),
+		},
+		{
+			key:           "c2hvcnQ=",
+			expectedError: errors.New("encryption key not 32 bytes long"),
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Encrypt("secret", c.key)
+
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	otherKey, err := GenerateKey()
+	assert.NoError(t, err)
+
+	encrypted, err := Encrypt("secret", otherKey)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		value         string
+		expectedError string
+	}{
+		{
+			value:         "secret",
+			expectedError: "value not encrypted",
+		},
+		{
+			value:         "ENC[aes128-cbc,abc]",
+			expectedError: "encryption algorithm not supported: aes128-cbc",
+		},
+		{
+			value:         "ENC[aes256-gcm,!!!]",
+			expectedError: "encrypted value not decoded: illegal base64 data at input byte 0",
+		},
+		{
+			value:         "ENC[aes256-gcm,YWJj]",
+			expectedError: "encrypted value too short",
+		},
+		{
+			value:         encrypted,
+			expectedError: "cipher: message authentication failed",
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Decrypt(c.value, testEncryptionKey)
+
+		assert.EqualError(t, err, c.expectedError)
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected bool
+	}{
+		{
+			value:    "",
+			expected: false,
+		},
+		{
+			value:    "secret",
+			expected: false,
+		},
+		{
+			value:    "ENC[aes256-gcm,abc",
+			expected: false,
+		},
+		{
+			value:    "ENC[aes256-gcm,abc]",
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, IsEncrypted(c.value))
+	}
+}
+
+func TestComic_decryptValues(t *testing.T) {
+	encrypted, err := Encrypt("secret", testEncryptionKey)
+	assert.NoError(t, err)
+
+	dir, remove := writeConfigFiles(map[string]string{
+		"key": testEncryptionKey + "\n",
+	})
+	defer remove()
+
+	cases := []struct {
+		opts           Options
+		envVars        map[string]string
+		values         map[string]interface{}
+		expectedOutput map[string]interface{}
+		expectedError  error
+	}{
+		{
+			opts: Options{},
+			values: map[string]interface{}{
+				"name": "app",
+			},
+			expectedOutput: map[string]interface{}{
+				"name": "app",
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{},
+			values: map[string]interface{}{
+				"db.password": encrypted,
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password": encrypted,
+			},
+			expectedError: errors.New("encryption key not configured"),
+		},
+		{
+			opts: Options{
+				EncryptionKeyFile: filepath.Join(dir, "key"),
+			},
+			values: map[string]interface{}{
+				"db.password":        encrypted,
+				"required.main.name": encrypted,
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password":        "secret",
+				"required.main.name": encrypted,
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				EncryptionKeyFile:   filepath.Join(dir, "missing"),
+				EncryptionKeyEnvVar: "COMIC_KEY",
+			},
+			envVars: map[string]string{
+				"COMIC_KEY": testEncryptionKey,
+			},
+			values: map[string]interface{}{
+				"db.password": encrypted,
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password": "secret",
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				EncryptionKeyFile: filepath.Join(dir, "key"),
+			},
+			values: map[string]interface{}{
+				"db.password": "ENC[aes256-gcm,YWJj]",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password": "ENC[aes256-gcm,YWJj]",
+			},
+			expectedError: errors.New("config 'db.password' not decrypted: encrypted value too short"),
+		},
+	}
+
+	for _, c := range cases {
+		keys := make(map[string]bool)
+		for key := range c.values {
+			keys[key] = true
+		}
+
+		vip := &mockViper{
+			keys:   keys,
+			values: c.values,
+		}
+		comic := NewWithOptions(c.opts)
+		comic.vip = vip
+
+		unset := setEnvVars(c.envVars)
+		err := comic.decryptValues()
+		unset()
+
+		assert.Equal(t, c.expectedOutput, vip.values)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_encryptedValues(t *testing.T) {
+	encrypted, err := Encrypt("secret", testEncryptionKey)
+	assert.NoError(t, err)
+
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: ` + encrypted + `
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"COMIC_KEY": testEncryptionKey,
+	})
+	defer unset()
+
+	cfg := &strictConfig{}
+	assert.NoError(t, NewWithOptions(Options{ConfigFilePath: dir, EncryptionKeyEnvVar: "COMIC_KEY"}).Load(cfg))
+	assert.Equal(t, "secret", cfg.Name)
+}
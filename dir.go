@@ -0,0 +1,75 @@
+package comic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefix of the names of hidden files (e.g. the ..data symlink & timestamped directories of Kubernetes volumes)
+const hiddenFilePrefix = "."
+
+// readConfigDirs returns the settings of all config directories merged in order, if there are any
+// otherwise, it returns nil
+func (c *Comic) readConfigDirs() (map[string]interface{}, error) {
+	var settings map[string]interface{}
+
+	for _, dir := range c.ConfigDirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		if settings == nil {
+			settings = make(map[string]interface{})
+		}
+
+		if err := readConfigDir(dir, "", settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+// readConfigDir sets the (trimmed) contents of all non-hidden files in the passed directory (recursively)
+// within the passed settings, at keys made of the passed prefix & their relative paths e.g. server/port => server.port
+//
+// note: symbolic links are followed, so that the files of Kubernetes volumes (linked through ..data) are read
+func readConfigDir(dir, prefix string, settings map[string]interface{}) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), hiddenFilePrefix) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		key := strings.ToLower(prefix + entry.Name())
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if err := readConfigDir(path, key+viperNestedKeySeparator, settings); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		setNestedValue(settings, key, strings.TrimSpace(string(content)))
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConfigDir(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"..2020_01_01/server.host": "localhost\n",
+		"..2020_01_01/NAME":        "app",
+		"nested/server/port":       "8080",
+		"nested/.hidden":           "x",
+	})
+	defer remove()
+
+	k8sDir := filepath.Join(dir, "k8s")
+	assert.NoError(t, os.Mkdir(k8sDir, 0755))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "..2020_01_01"), filepath.Join(k8sDir, "..data")))
+	assert.NoError(t, os.Symlink(filepath.Join("..data", "server.host"), filepath.Join(k8sDir, "server.host")))
+	assert.NoError(t, os.Symlink(filepath.Join("..data", "NAME"), filepath.Join(k8sDir, "NAME")))
+
+	cases := []struct {
+		dir      string
+		expected map[string]interface{}
+	}{
+		{
+			dir: k8sDir,
+			expected: map[string]interface{}{
+				"name":   "app",
+				"server": map[string]interface{}{"host": "localhost"},
+			},
+		},
+		{
+			dir: filepath.Join(dir, "nested"),
+			expected: map[string]interface{}{
+				"server": map[string]interface{}{"port": "8080"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		settings := make(map[string]interface{})
+
+		assert.NoError(t, readConfigDir(c.dir, "", settings))
+		assert.Equal(t, c.expected, settings)
+	}
+}
+
+func TestComic_LoadForCommand_configDirs(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: file
+server:
+  host: file
+required:
+  main:
+    server.port:
+`,
+		"configmap/name":        "configmap",
+		"configmap/server.host": "configmap",
+		"secret/server/port":    "8080",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"NAME": "env",
+	})
+	defer unset()
+
+	cases := []struct {
+		opts           Options
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{
+				ConfigFilePath: dir,
+				ConfigDirs:     []string{filepath.Join(dir, "configmap"), filepath.Join(dir, "secret"), filepath.Join(dir, "missing")},
+			},
+			expectedOutput: &layeredConfig{
+				Name: "env",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"configmap", 8080},
+			},
+		},
+		{
+			opts: Options{
+				ConfigFilePath: dir,
+				ConfigDirs:     []string{filepath.Join(dir, "configmap")},
+			},
+			expectedOutput: &layeredConfig{},
+			expectedError:  errors.New("required config for command 'main' missing: config not present: server.port"),
+		},
+		{
+			opts: Options{
+				ConfigFilePath: dir,
+				ConfigDirs:     []string{filepath.Join(dir, "config.yaml")},
+			},
+			expectedOutput: &layeredConfig{},
+			expectedError:  fmt.Errorf("config not loaded: config dir not read: open %s: not a directory", filepath.Join(dir, "config.yaml")),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
@@ -0,0 +1,267 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// UnambiguousEnvVarNestedKeySeparator can be used as EnvVarNestedKeySeparator to tell nesting apart from underscores
+	// in key names e.g. db.connections => DB__CONNECTIONS & db_connections => DB_CONNECTIONS
+	UnambiguousEnvVarNestedKeySeparator = "__"
+	// envVarNameSeparator separates the env prefix from the rest of the name of an environment variable
+	envVarNameSeparator = "_"
+	// suffix of the names of environment variables pointing to files that contain the values of config variables
+	fileEnvVarSuffix = "_FILE"
+)
+
+// envVarName returns the name of the environment variable corresponding to the passed key
+// e.g. server.port => MYAPP_SERVER_PORT (with MYAPP env prefix)
+func (c *Comic) envVarName(key string) string {
+	name := strings.ToUpper(key)
+	if c.EnvPrefix != "" {
+		name = strings.ToUpper(c.EnvPrefix) + envVarNameSeparator + name
+	}
+
+	return strings.NewReplacer(viperNestedKeySeparator, c.EnvVarNestedKeySeparator).Replace(name)
+}
+
+// lookupEnvVar returns the value of the passed environment variable and true, if it's set (and not empty)
+// otherwise, it returns an empty string and false
+func lookupEnvVar(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+
+	return value, ok && value != ""
+}
+
+// bindEnvVars binds the environment variables of all the leaf fields of the passed struct to their keys
+// so that they're loaded even if the keys are absent from config data file
+func (c *Comic) bindEnvVars(cfg interface{}) error {
+	for _, key := range getStructKeys(cfg) {
+		if err := c.vip.BindEnv(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commandEnvVarName returns the name of the environment variable corresponding to the passed key,
+// scoped by the passed command name i.e. each word of the command name is a level of nesting above the key
+// e.g. server.port => MYAPP_RUN_JOB_SERVER_PORT (for run job command, with MYAPP env prefix)
+//
+// note: hyphens in the command name are replaced with underscores e.g. sub-command => SUB_COMMAND
+func (c *Comic) commandEnvVarName(commandName, key string) string {
+	commandKey := strings.Join(strings.Fields(strings.Replace(commandName, "-", "_", -1)), viperNestedKeySeparator)
+
+	return c.envVarName(commandKey + viperNestedKeySeparator + key)
+}
+
+// overrideCommandEnvVars overrides the known & required (for the passed command name) config variables
+// that have command-scoped environment variables with their values, if CommandEnvVars is set
+func (c *Comic) overrideCommandEnvVars(cfg interface{}, commandName string) {
+	if !c.CommandEnvVars {
+		return
+	}
+
+	for _, key := range c.getKnownAndRequiredKeys(cfg, commandName) {
+		if value, ok := lookupEnvVar(c.commandEnvVarName(commandName, key)); ok {
+			c.override(key, value)
+		}
+	}
+}
+
+// overrideFileEnvVars overrides the known & required (for the passed command name) config variables
+// that have file environment variables (e.g. DB_PASSWORD_FILE) with the (trimmed) contents of the files they point to,
+// if FileEnvVars is set
+// an error is returned if a file can't be read or if both the file & the plain environment variables are set
+func (c *Comic) overrideFileEnvVars(cfg interface{}, commandName string) error {
+	if !c.FileEnvVars {
+		return nil
+	}
+
+	for _, key := range c.getKnownAndRequiredKeys(cfg, commandName) {
+		name := c.envVarName(key)
+
+		path, ok := lookupEnvVar(name + fileEnvVarSuffix)
+		if !ok {
+			continue
+		}
+
+		if _, ok := lookupEnvVar(name); ok {
+			return fmt.Errorf("both %s and %s set", name, name+fileEnvVarSuffix)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("file of %s not read: %s", name+fileEnvVarSuffix, err)
+		}
+
+		c.override(key, strings.TrimSpace(string(content)))
+	}
+
+	return nil
+}
+
+// getKnownKeys returns the keys of all config variables (excluding the sections used by Comic itself)
+// known either from Viper or from the fields of the passed struct
+func (c *Comic) getKnownKeys(cfg interface{}) (knownKeys []string) {
+	seen := make(map[string]bool)
+
+	for _, key := range append(c.vip.AllKeys(), getStructKeys(cfg)...) {
+		if key == "" || seen[key] || isReservedKey(key) {
+			continue
+		}
+
+		seen[key] = true
+		knownKeys = append(knownKeys, key)
+	}
+
+	return
+}
+
+// getKnownAndRequiredKeys returns the known config keys (see getKnownKeys) followed by the keys of the required
+// config variables of the passed command name that aren't among them
+// i.e. all the keys whose environment variables can set config variables while loading config for the command
+func (c *Comic) getKnownAndRequiredKeys(cfg interface{}, commandName string) []string {
+	keys := c.getKnownKeys(cfg)
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		seen[key] = true
+	}
+
+	for _, key := range c.getRequiredVarNames(commandName) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// checkUnknownEnvVars verifies that all environment variables with the env prefix map to a known config variable
+// (or a required one of the passed command name), if StrictEnvVars is set
+// the environment variables named by ConfigFileEnvVar, ProfileEnvVar & EncryptionKeyEnvVar are considered known,
+// as are the indexed & keyed environment variables of slice & map config variables,
+// as are the file environment variables of known config variables (if FileEnvVars is set)
+// and the command-scoped environment variables of all known commands, including the passed one (if CommandEnvVars is set)
+func (c *Comic) checkUnknownEnvVars(cfg interface{}, commandName string) error {
+	if !c.StrictEnvVars {
+		return nil
+	}
+
+	if c.EnvPrefix == "" {
+		return errors.New("env prefix empty")
+	}
+
+	var knownNames []string
+	isKnownName := make(map[string]bool)
+
+	addKnownName := func(name string) {
+		knownNames = append(knownNames, name)
+		isKnownName[name] = true
+	}
+
+	for _, name := range []string{c.ConfigFileEnvVar, c.ProfileEnvVar, c.EncryptionKeyEnvVar} {
+		if name != "" {
+			addKnownName(name)
+		}
+	}
+
+	_, collectionNames := c.getCollectionEnvVars(cfg)
+	for _, name := range collectionNames {
+		addKnownName(name)
+	}
+
+	for _, key := range c.getKnownAndRequiredKeys(cfg, commandName) {
+		addKnownName(c.envVarName(key))
+
+		if c.FileEnvVars {
+			addKnownName(c.envVarName(key) + fileEnvVarSuffix)
+		}
+
+		if !c.CommandEnvVars {
+			continue
+		}
+
+		for _, name := range append(c.getCommandNames(), commandName) {
+			addKnownName(c.commandEnvVarName(name, key))
+		}
+	}
+
+	var descriptions []string
+	prefix := strings.ToUpper(c.EnvPrefix) + envVarNameSeparator
+
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if !strings.HasPrefix(name, prefix) || isKnownName[name] {
+			continue
+		}
+
+		if suggestion := suggestKey(name, knownNames); suggestion != "" {
+			name = fmt.Sprintf("%s (did you mean %s?)", name, suggestion)
+		}
+
+		descriptions = append(descriptions, name)
+	}
+
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	sort.Strings(descriptions)
+
+	return fmt.Errorf("env vars unknown: %s", strings.Join(descriptions, ", "))
+}
+
+// EnvVarCollisions returns the keys of all known config variables (from Viper or the fields of the passed struct)
+// that share the same environment variable name, by environment variable name
+// e.g. DB_CONNECTIONS => [db.connections db_connections] (with _ as EnvVarNestedKeySeparator)
+func EnvVarCollisions(cfg interface{}) map[string][]string { return c.EnvVarCollisions(cfg) }
+func (c *Comic) EnvVarCollisions(cfg interface{}) map[string][]string {
+	keysByName := make(map[string][]string)
+
+	for _, key := range c.getKnownKeys(cfg) {
+		name := c.envVarName(key)
+		keysByName[name] = append(keysByName[name], key)
+	}
+
+	collisions := make(map[string][]string)
+
+	for name, keys := range keysByName {
+		if len(keys) > 1 {
+			sort.Strings(keys)
+			collisions[name] = keys
+		}
+	}
+
+	return collisions
+}
+
+// checkEnvVarCollisions verifies that no two known config variables share the same environment variable name,
+// if StrictEnvVarNames is set
+func (c *Comic) checkEnvVarCollisions(cfg interface{}) error {
+	if !c.StrictEnvVarNames {
+		return nil
+	}
+
+	collisions := c.EnvVarCollisions(cfg)
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, 0, len(collisions))
+	for name, keys := range collisions {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", name, strings.Join(keys, ", ")))
+	}
+
+	sort.Strings(descriptions)
+
+	return fmt.Errorf("env vars ambiguous: %s", strings.Join(descriptions, ", "))
+}
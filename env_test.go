@@ -0,0 +1,673 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setEnvVars sets the passed environment variables and returns a function to unset them
+func setEnvVars(vars map[string]string) (unset func()) {
+	for name, value := range vars {
+		os.Setenv(name, value)
+	}
+
+	return func() {
+		for name := range vars {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestComic_envVarName(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		key      string
+		expected string
+	}{
+		{
+			opts:     Options{},
+			key:      "port",
+			expected: "PORT",
+		},
+		{
+			opts:     Options{},
+			key:      "server.port",
+			expected: "SERVER_PORT",
+		},
+		{
+			opts: Options{
+				EnvVarNestedKeySeparator: "__",
+			},
+			key:      "db.max_connections",
+			expected: "DB__MAX_CONNECTIONS",
+		},
+		{
+			opts: Options{
+				EnvPrefix: "myapp",
+			},
+			key:      "server.port",
+			expected: "MYAPP_SERVER_PORT",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, NewWithOptions(c.opts).envVarName(c.key))
+	}
+}
+
+func TestComic_getKnownKeys(t *testing.T) {
+	c := &Comic{
+		vip: &mockViper{
+			keys: map[string]bool{
+				"name":              true,
+				"server.host":       true,
+				"required.run.name": false,
+				"aliases.run":       false,
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"name", "server.host", "server.port"}, c.getKnownKeys(&strictConfig{}))
+}
+
+func TestComic_checkUnknownEnvVars(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		envVars  map[string]string
+		expected error
+	}{
+		{
+			opts: Options{},
+			envVars: map[string]string{
+				"MYAPP_SERVER_PROT": "80",
+			},
+			expected: nil,
+		},
+		{
+			opts: Options{
+				StrictEnvVars: true,
+			},
+			envVars:  nil,
+			expected: errors.New("env prefix empty"),
+		},
+		{
+			opts: Options{
+				EnvPrefix:     "myapp",
+				StrictEnvVars: true,
+			},
+			envVars: map[string]string{
+				"MYAPP_NAME":        "app",
+				"MYAPP_SERVER_PORT": "80",
+				"MYAPPLICATION":     "app",
+				"SERVER_PROT":       "80",
+			},
+			expected: nil,
+		},
+		{
+			opts: Options{
+				EnvPrefix:     "myapp",
+				StrictEnvVars: true,
+			},
+			envVars: map[string]string{
+				"MYAPP_SERVER_PROT": "80",
+				"MYAPP_DEBUG":       "true",
+			},
+			expected: errors.New("env vars unknown: MYAPP_DEBUG, MYAPP_SERVER_PROT (did you mean MYAPP_SERVER_PORT?)"),
+		},
+		{
+			opts: Options{
+				EnvPrefix:     "myapp",
+				StrictEnvVars: true,
+			},
+			envVars: map[string]string{
+				"MYAPP_RUN_NAME": "app",
+			},
+			expected: errors.New("env vars unknown: MYAPP_RUN_NAME"),
+		},
+		{
+			opts: Options{
+				EnvPrefix:      "myapp",
+				StrictEnvVars:  true,
+				CommandEnvVars: true,
+			},
+			envVars: map[string]string{
+				"MYAPP_RUN_NAME":        "app",
+				"MYAPP_RUN_SERVER_PORT": "80",
+			},
+			expected: nil,
+		},
+		{
+			opts: Options{
+				EnvPrefix:           "myapp",
+				StrictEnvVars:       true,
+				ConfigFileEnvVar:    "MYAPP_CONFIG",
+				ProfileEnvVar:       "MYAPP_PROFILE",
+				EncryptionKeyEnvVar: "MYAPP_KEY",
+			},
+			envVars: map[string]string{
+				"MYAPP_CONFIG":  "/etc/myapp.yaml",
+				"MYAPP_PROFILE": "production",
+				"MYAPP_KEY":     "key",
+			},
+			expected: nil,
+		},
+		{
+			opts: Options{
+				EnvPrefix:     "comic",
+				StrictEnvVars: true,
+			},
+			envVars: map[string]string{
+				"COMIC_PROFILE": "production",
+				"COMIC_CONFIG":  "/etc/comic.yaml",
+			},
+			expected: errors.New("env vars unknown: COMIC_CONFIG"),
+		},
+	}
+
+	for _, c := range cases {
+		comic := NewWithOptions(c.opts)
+		comic.vip = &mockViper{
+			keys: map[string]bool{
+				"name":              true,
+				"required.run.name": false,
+			},
+		}
+
+		unset := setEnvVars(c.envVars)
+		err := comic.checkUnknownEnvVars(&strictConfig{}, "run")
+		unset()
+
+		assert.Equal(t, c.expected, err)
+	}
+}
+
+func TestComic_bindEnvVars(t *testing.T) {
+	vip := &mockViper{}
+	c := &Comic{
+		vip: vip,
+	}
+
+	assert.NoError(t, c.bindEnvVars(&strictConfig{}))
+	assert.Equal(t, []string{"name", "server.host", "server.port"}, vip.boundKeys)
+}
+
+func TestComic_LoadForCommand_envOnlyKeys(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: app
+required:
+  main:
+    server:
+      port:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"SERVER_HOST": "localhost",
+		"SERVER_PORT": "80",
+	})
+	defer unset()
+
+	cfg := &strictConfig{}
+	err := NewWithOptions(Options{ConfigFilePath: dir}).Load(cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, "localhost", cfg.Server.Host)
+	assert.Equal(t, 80, cfg.Server.Port)
+}
+
+type collidingConfig struct {
+	DBConnections int `mapstructure:"db_connections"`
+	DB            struct {
+		Connections int `mapstructure:"connections"`
+		Host        string
+	} `mapstructure:"db"`
+	DBHost string `mapstructure:"db_host"`
+}
+
+func TestEnvVarCollisions(t *testing.T) {
+	defer func(original *Comic) { c = original }(c)
+	c = New()
+
+	expected := map[string][]string{
+		"DB_CONNECTIONS": {"db.connections", "db_connections"},
+		"DB_HOST":        {"db.host", "db_host"},
+	}
+
+	assert.Equal(t, expected, EnvVarCollisions(&collidingConfig{}))
+}
+
+func TestComic_EnvVarCollisions(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		keys     map[string]bool
+		expected map[string][]string
+	}{
+		{
+			opts: Options{},
+			keys: nil,
+			expected: map[string][]string{
+				"DB_CONNECTIONS": {"db.connections", "db_connections"},
+				"DB_HOST":        {"db.host", "db_host"},
+			},
+		},
+		{
+			opts: Options{},
+			keys: map[string]bool{
+				"db_port": true,
+				"db.port": true,
+			},
+			expected: map[string][]string{
+				"DB_CONNECTIONS": {"db.connections", "db_connections"},
+				"DB_HOST":        {"db.host", "db_host"},
+				"DB_PORT":        {"db.port", "db_port"},
+			},
+		},
+		{
+			opts: Options{
+				EnvVarNestedKeySeparator: UnambiguousEnvVarNestedKeySeparator,
+			},
+			keys: map[string]bool{
+				"db_port": true,
+				"db.port": true,
+			},
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, c := range cases {
+		comic := NewWithOptions(c.opts)
+		comic.vip = &mockViper{
+			keys: c.keys,
+		}
+
+		assert.Equal(t, c.expected, comic.EnvVarCollisions(&collidingConfig{}))
+	}
+}
+
+func TestComic_checkEnvVarCollisions(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		expected error
+	}{
+		{
+			opts:     Options{},
+			expected: nil,
+		},
+		{
+			opts: Options{
+				StrictEnvVarNames: true,
+			},
+			expected: errors.New("env vars ambiguous: DB_CONNECTIONS (db.connections, db_connections), DB_HOST (db.host, db_host)"),
+		},
+		{
+			opts: Options{
+				EnvVarNestedKeySeparator: UnambiguousEnvVarNestedKeySeparator,
+				StrictEnvVarNames:        true,
+			},
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		comic := NewWithOptions(c.opts)
+		comic.vip = &mockViper{}
+
+		assert.Equal(t, c.expected, comic.checkEnvVarCollisions(&collidingConfig{}))
+	}
+}
+
+func TestComic_LoadForCommand_unambiguousEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+db_host: localhost
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"DB__CONNECTIONS": "5",
+		"DB_CONNECTIONS":  "10",
+		"DB__HOST":        "remote",
+	})
+	defer unset()
+
+	cfg := &collidingConfig{}
+	err := NewWithOptions(Options{
+		ConfigFilePath:           dir,
+		EnvVarNestedKeySeparator: UnambiguousEnvVarNestedKeySeparator,
+		StrictEnvVarNames:        true,
+	}).Load(cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, cfg.DB.Connections)
+	assert.Equal(t, 10, cfg.DBConnections)
+	assert.Equal(t, "remote", cfg.DB.Host)
+	assert.Equal(t, "localhost", cfg.DBHost)
+}
+
+func TestComic_LoadForCommand_envPrefix(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: app
+required:
+  main:
+    server:
+      port:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"PORT":              "1",
+		"SERVER_PORT":       "2",
+		"MYAPP_SERVER_PORT": "80",
+		"OTHER_NAME":        "other",
+	})
+	defer unset()
+
+	myApp := &strictConfig{}
+	assert.NoError(t, NewWithOptions(Options{ConfigFilePath: dir, EnvPrefix: "myapp"}).Load(myApp))
+	assert.Equal(t, "app", myApp.Name)
+	assert.Equal(t, 80, myApp.Server.Port)
+
+	other := &strictConfig{}
+	err := NewWithOptions(Options{ConfigFilePath: dir, EnvPrefix: "other"}).Load(other)
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: server.port (env var OTHER_SERVER_PORT)"), err)
+}
+
+func TestComic_commandEnvVarName(t *testing.T) {
+	cases := []struct {
+		opts        Options
+		commandName string
+		key         string
+		expected    string
+	}{
+		{
+			opts:        Options{},
+			commandName: "api",
+			key:         "server.port",
+			expected:    "API_SERVER_PORT",
+		},
+		{
+			opts:        Options{},
+			commandName: "run job",
+			key:         "port",
+			expected:    "RUN_JOB_PORT",
+		},
+		{
+			opts:        Options{},
+			commandName: "command sub-command",
+			key:         "port",
+			expected:    "COMMAND_SUB_COMMAND_PORT",
+		},
+		{
+			opts: Options{
+				EnvPrefix:                "myapp",
+				EnvVarNestedKeySeparator: UnambiguousEnvVarNestedKeySeparator,
+			},
+			commandName: "run job",
+			key:         "server.port",
+			expected:    "MYAPP_RUN__JOB__SERVER__PORT",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, NewWithOptions(c.opts).commandEnvVarName(c.commandName, c.key))
+	}
+}
+
+func TestComic_overrideCommandEnvVars(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		expected map[string]interface{}
+	}{
+		{
+			opts:     Options{},
+			expected: nil,
+		},
+		{
+			opts: Options{
+				CommandEnvVars: true,
+			},
+			expected: map[string]interface{}{
+				"server.port": "81",
+			},
+		},
+	}
+
+	unset := setEnvVars(map[string]string{
+		"API_SERVER_PORT":     "81",
+		"API_NAME":            "",
+		"INDEXER_SERVER_PORT": "82",
+	})
+	defer unset()
+
+	for _, c := range cases {
+		vip := &mockViper{}
+		comic := NewWithOptions(c.opts)
+		comic.vip = vip
+
+		comic.overrideCommandEnvVars(&strictConfig{}, "api")
+
+		assert.Equal(t, c.expected, vip.values)
+	}
+}
+
+func TestComic_LoadForCommand_commandEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: app
+required:
+  api:
+    server:
+      host:
+  indexer:
+    name:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"SERVER_PORT":     "80",
+		"API_SERVER_PORT": "81",
+		"API_SERVER_HOST": "localhost",
+	})
+	defer unset()
+
+	comic := NewWithOptions(Options{ConfigFilePath: dir, CommandEnvVars: true})
+
+	api := &strictConfig{}
+	assert.NoError(t, comic.LoadForCommand(api, "api"))
+	assert.Equal(t, "localhost", api.Server.Host)
+	assert.Equal(t, 81, api.Server.Port)
+
+	indexer := &strictConfig{}
+	assert.NoError(t, comic.LoadForCommand(indexer, "indexer"))
+	assert.Equal(t, "", indexer.Server.Host)
+	assert.Equal(t, 80, indexer.Server.Port)
+
+	err := NewWithOptions(Options{ConfigFilePath: dir}).LoadForCommand(&strictConfig{}, "api")
+	assert.Equal(t, errors.New("required config for command 'api' missing: config not present: server.host"), err)
+}
+
+func TestComic_LoadForCommand_requiredCommandEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+required:
+  api:
+    token:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"MYAPP_API_TOKEN": "secret",
+	})
+	defer unset()
+
+	opts := Options{ConfigFilePath: dir, EnvPrefix: "myapp", CommandEnvVars: true, StrictEnvVars: true}
+
+	comic := NewWithOptions(opts)
+	assert.NoError(t, comic.LoadForCommand(&strictConfig{}, "api"))
+	assert.Equal(t, "secret", comic.Viper().Get("token"))
+
+	opts.CommandEnvVars = false
+	opts.StrictEnvVars = false
+
+	err := NewWithOptions(opts).LoadForCommand(&strictConfig{}, "api")
+	assert.Equal(t, errors.New("required config for command 'api' missing: config not present: token (env var MYAPP_TOKEN)"), err)
+}
+
+func TestComic_overrideFileEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"name": " app\n",
+		"port": "80",
+	})
+	defer remove()
+
+	cases := []struct {
+		opts           Options
+		envVars        map[string]string
+		expectedOutput map[string]interface{}
+		expectedError  error
+	}{
+		{
+			opts: Options{},
+			envVars: map[string]string{
+				"NAME_FILE": filepath.Join(dir, "name"),
+			},
+			expectedOutput: nil,
+			expectedError:  nil,
+		},
+		{
+			opts: Options{
+				FileEnvVars: true,
+			},
+			envVars: map[string]string{
+				"NAME_FILE":        filepath.Join(dir, "name"),
+				"SERVER_PORT_FILE": filepath.Join(dir, "port"),
+			},
+			expectedOutput: map[string]interface{}{
+				"name":        "app",
+				"server.port": "80",
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				FileEnvVars: true,
+				EnvPrefix:   "myapp",
+			},
+			envVars: map[string]string{
+				"MYAPP_NAME_FILE": filepath.Join(dir, "missing"),
+			},
+			expectedOutput: nil,
+			expectedError:  fmt.Errorf("file of MYAPP_NAME_FILE not read: open %s: no such file or directory", filepath.Join(dir, "missing")),
+		},
+		{
+			opts: Options{
+				FileEnvVars: true,
+			},
+			envVars: map[string]string{
+				"NAME":      "app",
+				"NAME_FILE": filepath.Join(dir, "name"),
+			},
+			expectedOutput: nil,
+			expectedError:  errors.New("both NAME and NAME_FILE set"),
+		},
+	}
+
+	for _, c := range cases {
+		vip := &mockViper{}
+		comic := NewWithOptions(c.opts)
+		comic.vip = vip
+
+		unset := setEnvVars(c.envVars)
+		err := comic.overrideFileEnvVars(&strictConfig{}, "main")
+		unset()
+
+		assert.Equal(t, c.expectedOutput, vip.values)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_fileEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+required:
+  main:
+    name:
+`,
+		"secrets/name": "secret\n",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"NAME_FILE": filepath.Join(dir, "secrets", "name"),
+	})
+	defer unset()
+
+	cfg := &strictConfig{}
+	assert.NoError(t, NewWithOptions(Options{ConfigFilePath: dir, FileEnvVars: true}).Load(cfg))
+	assert.Equal(t, "secret", cfg.Name)
+
+	err := NewWithOptions(Options{ConfigFilePath: dir}).Load(&strictConfig{})
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), err)
+}
+
+func TestComic_LoadForCommand_requiredFileEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+required:
+  api:
+    token:
+`,
+		"secrets/token": "secret\n",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"TOKEN_FILE": filepath.Join(dir, "secrets", "token"),
+	})
+	defer unset()
+
+	comic := NewWithOptions(Options{ConfigFilePath: dir, FileEnvVars: true})
+	assert.NoError(t, comic.LoadForCommand(&strictConfig{}, "api"))
+	assert.Equal(t, "secret", comic.Viper().Get("token"))
+
+	err := NewWithOptions(Options{ConfigFilePath: dir}).LoadForCommand(&strictConfig{}, "api")
+	assert.Equal(t, errors.New("required config for command 'api' missing: config not present: token"), err)
+}
+
+func TestComic_LoadForCommand_strictControlEnvVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"app.yaml":            "name: app",
+		"app.production.yaml": "name: production",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"MYAPP_CONFIG":  filepath.Join(dir, "app.yaml"),
+		"MYAPP_PROFILE": "production",
+	})
+	defer unset()
+
+	cfg := &strictConfig{}
+	comic := NewWithOptions(Options{
+		EnvPrefix:        "myapp",
+		StrictEnvVars:    true,
+		ConfigFileEnvVar: "MYAPP_CONFIG",
+		ProfileEnvVar:    "MYAPP_PROFILE",
+	})
+
+	assert.NoError(t, comic.Load(cfg))
+	assert.Equal(t, "production", cfg.Name)
+}
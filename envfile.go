@@ -0,0 +1,155 @@
+package comic
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// prefix of the lines of .env files that are comments
+	envFileCommentPrefix = "#"
+	// optional prefix of the lines of .env files that set variables (as in shell scripts)
+	envFileExportPrefix = "export "
+)
+
+// overrideEnvFileVars overrides the known & required (for the passed command name) config variables
+// that have environment variables in the .env files with their values, unless they're set in the environment
+// an error is returned if any of the files can't be read or parsed
+//
+// note: absent .env files are ignored
+func (c *Comic) overrideEnvFileVars(cfg interface{}, commandName string) error {
+	values := make(map[string]string)
+
+	for _, file := range c.EnvFiles {
+		fileValues, err := readEnvFile(file)
+		if err != nil {
+			return err
+		}
+
+		for name, value := range fileValues {
+			values[name] = value
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	for _, key := range c.getKnownAndRequiredKeys(cfg, commandName) {
+		name := c.envVarName(key)
+
+		if _, ok := lookupEnvVar(name); ok {
+			continue
+		}
+
+		if value, ok := values[name]; ok && value != "" {
+			c.override(key, value)
+		}
+	}
+
+	return nil
+}
+
+// readEnvFile returns the values of the environment variables in the passed .env file, by name
+// if there is no such file, it returns nil
+func readEnvFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values, err := parseEnvFile(bufio.NewScanner(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	return values, nil
+}
+
+// parseEnvFile returns the values of the environment variables in the lines of the passed scanner, by name
+// each line is either empty, a comment (i.e. # ...) or NAME=value (optionally prefixed with export),
+// where the value can be single-quoted (i.e. taken literally), double-quoted (i.e. with escape sequences)
+// or unquoted (i.e. up to a comment), and quoted values can be followed by a comment
+func parseEnvFile(scanner *bufio.Scanner) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, envFileCommentPrefix) {
+			continue
+		}
+
+		nameValue := strings.SplitN(strings.TrimPrefix(line, envFileExportPrefix), "=", 2)
+		if len(nameValue) != 2 || strings.TrimSpace(nameValue[0]) == "" {
+			return nil, fmt.Errorf("line %d: name=value expected", lineNumber)
+		}
+
+		value, err := parseEnvFileValue(strings.TrimSpace(nameValue[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+
+		values[strings.TrimSpace(nameValue[0])] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// parseEnvFileValue returns the passed (trimmed) value of a .env file line, unquoted
+// a quoted value can only be followed by a comment
+func parseEnvFileValue(value string) (string, error) {
+	end := -1
+
+	switch {
+	case strings.HasPrefix(value, "'"):
+		if i := strings.Index(value[1:], "'"); i >= 0 {
+			end = i + 1
+		}
+	case strings.HasPrefix(value, `"`):
+		end = findClosingDoubleQuote(value)
+		if end < 0 {
+			return strconv.Unquote(value)
+		}
+	}
+
+	if end < 0 {
+		if i := strings.Index(value, " "+envFileCommentPrefix); i >= 0 {
+			value = value[:i]
+		}
+
+		return strings.TrimSpace(value), nil
+	}
+
+	if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, envFileCommentPrefix) {
+		return "", fmt.Errorf("text after closing quote: %s", rest)
+	}
+
+	if value[0] == '\'' {
+		return value[1:end], nil
+	}
+
+	return strconv.Unquote(value[:end+1])
+}
+
+// findClosingDoubleQuote returns the index of the double quote closing the one the passed value starts with
+// i.e. the first one that isn't escaped with a backslash, or -1 if there is none
+func findClosingDoubleQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
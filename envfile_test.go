@@ -0,0 +1,150 @@
+package comic
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	cases := []struct {
+		content        string
+		expectedOutput map[string]string
+		expectedError  error
+	}{
+		{
+			content:        "",
+			expectedOutput: map[string]string{},
+		},
+		{
+			content: `
+# comment
+NAME=app
+export SERVER_HOST = localhost # comment
+SERVER_PORT=
+`,
+			expectedOutput: map[string]string{"NAME": "app", "SERVER_HOST": "localhost", "SERVER_PORT": ""},
+		},
+		{
+			content:        `A='a # b \n' ` + "\n" + `B="a # b \n"` + "\n" + `C=a#b`,
+			expectedOutput: map[string]string{"A": `a # b \n`, "B": "a # b \n", "C": "a#b"},
+		},
+		{
+			content:        `A="a # b" # comment` + "\n" + `B='a # b' # comment` + "\n" + `C="a \" b"#comment` + "\n" + `D='a`,
+			expectedOutput: map[string]string{"A": "a # b", "B": "a # b", "C": `a " b`, "D": "'a"},
+		},
+		{
+			content:       `NAME="app" suffix`,
+			expectedError: errors.New("line 1: text after closing quote: suffix"),
+		},
+		{
+			content:       "NAME=app\nSERVER_HOST",
+			expectedError: errors.New("line 2: name=value expected"),
+		},
+		{
+			content:       `NAME="app`,
+			expectedError: errors.New("line 1: invalid syntax"),
+		},
+	}
+
+	for _, c := range cases {
+		values, err := parseEnvFile(bufio.NewScanner(strings.NewReader(c.content)))
+
+		assert.Equal(t, c.expectedOutput, values)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_envFiles(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: file
+required:
+  main:
+    server.host:
+    server.port:
+`,
+		".env": `
+NAME=env-file
+SERVER_HOST=localhost
+SERVER_PORT=80
+`,
+		".env.local": `
+SERVER_PORT=8080
+`,
+		".env.malformed": `
+SERVER_PORT
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"NAME": "env",
+	})
+	defer unset()
+
+	envFile, localEnvFile, malformedEnvFile := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.local"), filepath.Join(dir, ".env.malformed")
+
+	cases := []struct {
+		opts           Options
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{ConfigFilePath: dir, EnvFiles: []string{envFile, localEnvFile, filepath.Join(dir, ".env.missing")}},
+			expectedOutput: &layeredConfig{
+				Name: "env",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 8080},
+			},
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, EnvFiles: []string{localEnvFile}},
+			expectedOutput: &layeredConfig{},
+			expectedError:  errors.New("required config for command 'main' missing: config not present: server.host"),
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, EnvFiles: []string{malformedEnvFile}},
+			expectedOutput: &layeredConfig{},
+			expectedError:  fmt.Errorf("env file not read: %s: line 2: name=value expected", malformedEnvFile),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_envFileRequiredVars(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+required:
+  main:
+    db.url:
+`,
+		".env": `
+MYAPP_DB__URL=postgres://localhost
+`,
+	})
+	defer remove()
+
+	opts := Options{
+		ConfigFilePath:           dir,
+		EnvFiles:                 []string{filepath.Join(dir, ".env")},
+		EnvPrefix:                "myapp",
+		EnvVarNestedKeySeparator: UnambiguousEnvVarNestedKeySeparator,
+	}
+
+	assert.NoError(t, NewWithOptions(opts).Load(&layeredConfig{}))
+}
@@ -0,0 +1,76 @@
+package comic
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// key of the directive used to include other config data files in a config data file
+	includeKey = "include"
+	// key of the directive used to extend another config data file in a config data file
+	extendsKey = "extends"
+	// separator of the config data files in an include chain
+	includeChainSeparator = " -> "
+)
+
+// readConfigFile returns the settings of the passed config data file merged on top of the ones of the files it extends
+// & includes (i.e. extends < include[0] < include[1] < ... < the file itself), resolved relative to it (recursively)
+// along with the paths of all files read
+// an error including the passed chain of files including the file is returned if any of the files can't be read
+// or if they include each other in a cycle
+func (c *Comic) readConfigFile(file string, chain []string) (settings map[string]interface{}, files []string, err error) {
+	chain = append(append([]string(nil), chain...), file)
+
+	for _, includingFile := range chain[:len(chain)-1] {
+		if includingFile == file {
+			return nil, nil, fmt.Errorf("include cycle: %s", strings.Join(chain, includeChainSeparator))
+		}
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", strings.Join(chain, includeChainSeparator), err)
+	}
+
+	fileSettings := getRawSettings(v)
+	files = []string{file}
+
+	var includedFiles []string
+	for _, key := range []string{extendsKey, includeKey} {
+		for _, includedFile := range toList(fileSettings[key]) {
+			includedFiles = append(includedFiles, fmt.Sprint(includedFile))
+		}
+
+		delete(fileSettings, key)
+	}
+
+	if len(includedFiles) == 0 {
+		return fileSettings, files, nil
+	}
+
+	settings = make(map[string]interface{})
+
+	for _, includedFile := range includedFiles {
+		if !filepath.IsAbs(includedFile) {
+			includedFile = filepath.Join(filepath.Dir(file), includedFile)
+		}
+
+		includedSettings, includedFilesRead, err := c.readConfigFile(includedFile, chain)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergeSettings(settings, includedSettings, c.SliceMerge)
+		files = append(files, includedFilesRead...)
+	}
+
+	mergeSettings(settings, fileSettings, c.SliceMerge)
+
+	return settings, files, nil
+}
@@ -0,0 +1,126 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComic_LoadForCommand_includes(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"base.yaml": `
+name: base
+origins: [a]
+server:
+  host: localhost
+  port: 80
+required:
+  main:
+    name:
+`,
+		"app/config.yaml": `
+extends: ../base.yaml
+include: [db.yaml, shared/server.yaml]
+name: app
+`,
+		"app/db.yaml": `
+origins: [b]
+required:
+  main:
+    server.port:
+`,
+		"app/shared/server.yaml": `
+server:
+  host: remote
+`,
+		"cycle/config.yaml": `
+include: a.yaml
+`,
+		"cycle/a.yaml": `
+include: b.yaml
+`,
+		"cycle/b.yaml": `
+extends: a.yaml
+`,
+		"missing/config.yaml": `
+include: [db.yaml]
+`,
+	})
+	defer remove()
+
+	cases := []struct {
+		opts           Options
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{ConfigFilePath: filepath.Join(dir, "app"), StrictKeys: true},
+			expectedOutput: &layeredConfig{
+				Name:    "app",
+				Origins: []string{"b"},
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"remote", 80},
+			},
+		},
+		{
+			opts: Options{ConfigFilePath: filepath.Join(dir, "app"), SliceMerge: SliceMergeAppend},
+			expectedOutput: &layeredConfig{
+				Name:    "app",
+				Origins: []string{"a", "b"},
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"remote", 80},
+			},
+		},
+		{
+			opts:           Options{ConfigFilePath: filepath.Join(dir, "cycle")},
+			expectedOutput: &layeredConfig{},
+			expectedError: fmt.Errorf("config not loaded: include cycle: %s -> %s -> %s -> %s",
+				filepath.Join(dir, "cycle", "config.yaml"),
+				filepath.Join(dir, "cycle", "a.yaml"),
+				filepath.Join(dir, "cycle", "b.yaml"),
+				filepath.Join(dir, "cycle", "a.yaml")),
+		},
+		{
+			opts:           Options{ConfigFilePath: filepath.Join(dir, "missing")},
+			expectedOutput: &layeredConfig{},
+			expectedError: fmt.Errorf("config not loaded: %s -> %s: open %s: no such file or directory",
+				filepath.Join(dir, "missing", "config.yaml"),
+				filepath.Join(dir, "missing", "db.yaml"),
+				filepath.Join(dir, "missing", "db.yaml")),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_includedRequiredSections(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+include: required.yaml
+name: app
+`,
+		"required.yaml": `
+required:
+  main:
+    server.host:
+`,
+	})
+	defer remove()
+
+	err := NewWithOptions(Options{ConfigFilePath: dir}).Load(&layeredConfig{})
+
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: server.host"), err)
+}
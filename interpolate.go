@@ -0,0 +1,153 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// opening of references within config values e.g. ${DB_HOST} or ${db.host}
+	interpolationOpening = "${"
+	// closing of references within config values
+	interpolationClosing = "}"
+	// character escaping the opening of references, which is then interpolated as a literal opening i.e. $${ => ${
+	interpolationEscape = '$'
+	// separator of the default value of references e.g. ${DB_HOST:-localhost}
+	interpolationDefaultSeparator = ":-"
+)
+
+// interpolateValues overrides all config variables (excluding the sections used by Comic itself)
+// containing references e.g. ${DB_HOST}, ${DB_HOST:-localhost} or ${db.host} with interpolated values,
+// if InterpolateValues is set
+//
+// a reference is to a config variable if its name is lowercase and contains a dot or is the key of a config variable,
+// otherwise, it's to an environment variable
+func (c *Comic) interpolateValues() error {
+	if !c.InterpolateValues {
+		return nil
+	}
+
+	keys := c.vip.AllKeys()
+	sort.Strings(keys)
+
+	interpolated := make(map[string]string)
+
+	for _, key := range keys {
+		if isReservedKey(key) {
+			continue
+		}
+
+		value, ok := c.vip.Get(key).(string)
+		if !ok || !strings.Contains(value, interpolationOpening) {
+			continue
+		}
+
+		if _, err := c.interpolateKey(key, nil, interpolated); err != nil {
+			return fmt.Errorf("config '%s' not interpolated: %s", key, err)
+		}
+	}
+
+	for key, value := range interpolated {
+		c.override(key, value)
+	}
+
+	return nil
+}
+
+// interpolateKey returns the interpolated value of the config variable of the passed key
+// keys being interpolated (that refer to it) are passed to detect cycles,
+// while interpolated values are cached by key
+func (c *Comic) interpolateKey(key string, referrers []string, interpolated map[string]string) (string, error) {
+	for i, referrer := range referrers {
+		if referrer == key {
+			return "", fmt.Errorf("reference cycle: %s -> %s", strings.Join(referrers[i:], " -> "), key)
+		}
+	}
+
+	if value, ok := interpolated[key]; ok {
+		return value, nil
+	}
+
+	value := fmt.Sprint(c.vip.Get(key))
+	if !strings.Contains(value, interpolationOpening) {
+		return value, nil
+	}
+
+	value, err := interpolate(value, func(name string) (string, bool, error) {
+		if !c.isKeyReference(name) {
+			value, ok := lookupEnvVar(name)
+			return value, ok, nil
+		}
+
+		if !c.vip.IsSet(name) {
+			return "", false, nil
+		}
+
+		value, err := c.interpolateKey(name, append(referrers, key), interpolated)
+
+		return value, true, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	interpolated[key] = value
+
+	return value, nil
+}
+
+// isKeyReference checks if the passed reference name is the key of a config variable
+// i.e. it's lowercase and contains a dot or is the key of a config variable
+func (c *Comic) isKeyReference(name string) bool {
+	return name == strings.ToLower(name) && (strings.Contains(name, viperNestedKeySeparator) || c.vip.IsSet(name))
+}
+
+// interpolate replaces all references within the passed value with the values returned by the passed lookup function
+// or their default values, if the lookup function returns no value
+// an error is returned if a reference is unterminated or has neither a value nor a default value
+func interpolate(value string, lookup func(name string) (string, bool, error)) (string, error) {
+	var b strings.Builder
+
+	for {
+		start := strings.Index(value, interpolationOpening)
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+
+		if start > 0 && value[start-1] == interpolationEscape {
+			b.WriteString(value[:start-1] + interpolationOpening)
+			value = value[start+len(interpolationOpening):]
+			continue
+		}
+
+		end := strings.Index(value[start:], interpolationClosing)
+		if end < 0 {
+			return "", errors.New("reference not terminated: " + value[start:])
+		}
+
+		b.WriteString(value[:start])
+
+		reference := value[start+len(interpolationOpening) : start+end]
+		value = value[start+end+len(interpolationClosing):]
+
+		nameDefault := strings.SplitN(reference, interpolationDefaultSeparator, 2)
+
+		resolved, ok, err := lookup(nameDefault[0])
+		if err != nil {
+			return "", err
+		}
+
+		if !ok {
+			if len(nameDefault) < 2 {
+				return "", fmt.Errorf("reference not set: %s", nameDefault[0])
+			}
+
+			resolved = nameDefault[1]
+		}
+
+		b.WriteString(resolved)
+	}
+}
@@ -0,0 +1,199 @@
+package comic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComic_interpolateValues(t *testing.T) {
+	cases := []struct {
+		opts           Options
+		values         map[string]interface{}
+		expectedOutput map[string]interface{}
+		expectedError  error
+	}{
+		{
+			opts: Options{},
+			values: map[string]interface{}{
+				"db.url": "postgres://${DB_USER}@localhost",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.url": "postgres://${DB_USER}@localhost",
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				InterpolateValues: true,
+			},
+			values: map[string]interface{}{
+				"db.user":            "app",
+				"db.host":            "${DB_HOST:-localhost}",
+				"db.port":            5432,
+				"db.url":             "postgres://${db.user}@${db.host}:${db.port}/${DB_NAME}",
+				"ttl":                "30s",
+				"cache.ttl":          "${ttl}",
+				"home":               "${HOME_DIR}",
+				"template":           "$${NOT_A_REFERENCE}",
+				"required.main.name": "${MISSING}",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.user":            "app",
+				"db.host":            "localhost",
+				"db.port":            5432,
+				"db.url":             "postgres://app@localhost:5432/main",
+				"ttl":                "30s",
+				"cache.ttl":          "30s",
+				"home":               "/home/app",
+				"template":           "${NOT_A_REFERENCE}",
+				"required.main.name": "${MISSING}",
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				InterpolateValues: true,
+			},
+			values: map[string]interface{}{
+				"a":   "${b}",
+				"b":   "x${c.d}",
+				"c.d": "${a}",
+			},
+			expectedOutput: map[string]interface{}{
+				"a":   "${b}",
+				"b":   "x${c.d}",
+				"c.d": "${a}",
+			},
+			expectedError: errors.New("config 'a' not interpolated: reference cycle: a -> b -> c.d -> a"),
+		},
+		{
+			opts: Options{
+				InterpolateValues: true,
+			},
+			values: map[string]interface{}{
+				"db.url": "postgres://${db.user}@localhost",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.url": "postgres://${db.user}@localhost",
+			},
+			expectedError: errors.New("config 'db.url' not interpolated: reference not set: db.user"),
+		},
+		{
+			opts: Options{
+				InterpolateValues: true,
+			},
+			values: map[string]interface{}{
+				"db.url": "postgres://${DB_USER",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.url": "postgres://${DB_USER",
+			},
+			expectedError: errors.New("config 'db.url' not interpolated: reference not terminated: ${DB_USER"),
+		},
+	}
+
+	unset := setEnvVars(map[string]string{
+		"DB_NAME":  "main",
+		"HOME_DIR": "/home/app",
+	})
+	defer unset()
+
+	for _, c := range cases {
+		keys := make(map[string]bool)
+		for key := range c.values {
+			keys[key] = true
+		}
+
+		vip := &mockViper{
+			keys:   keys,
+			values: c.values,
+		}
+		comic := NewWithOptions(c.opts)
+		comic.vip = vip
+
+		err := comic.interpolateValues()
+
+		assert.Equal(t, c.expectedOutput, vip.values)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	lookup := func(name string) (string, bool, error) {
+		switch name {
+		case "A":
+			return "a", true, nil
+		case "ERR":
+			return "", false, errors.New("failed")
+		}
+
+		return "", false, nil
+	}
+
+	cases := []struct {
+		value          string
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			value:          "",
+			expectedOutput: "",
+		},
+		{
+			value:          "plain",
+			expectedOutput: "plain",
+		},
+		{
+			value:          "${A}-${A}",
+			expectedOutput: "a-a",
+		},
+		{
+			value:          "${B:-b}${B:-}",
+			expectedOutput: "b",
+		},
+		{
+			value:          "$${A} ${A}",
+			expectedOutput: "${A} a",
+		},
+		{
+			value:         "${B}",
+			expectedError: errors.New("reference not set: B"),
+		},
+		{
+			value:         "${ERR:-x}",
+			expectedError: errors.New("failed"),
+		},
+	}
+
+	for _, c := range cases {
+		value, err := interpolate(c.value, lookup)
+
+		assert.Equal(t, c.expectedOutput, value)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_interpolation(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: ${APP_NAME:-app}-${server.host}
+server:
+  host: localhost
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"SERVER_HOST": "remote",
+	})
+	defer unset()
+
+	cfg := &strictConfig{}
+	assert.NoError(t, NewWithOptions(Options{ConfigFilePath: dir, InterpolateValues: true}).Load(cfg))
+	assert.Equal(t, "app-remote", cfg.Name)
+}
@@ -0,0 +1,185 @@
+package comic
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+)
+
+const (
+	// name of the struct tag used to map config variables to struct fields
+	mapstructureTagName = "mapstructure"
+	// name of the struct tag used to set Comic-specific options of struct fields e.g. `comic:"required"`
+	comicTagName = "comic"
+	// option of the comic struct tag making the config variables of a field (or of all fields under it) required
+	requiredTagOption = "required"
+	// maximum edit distance between an unknown key and a known key for the latter to be suggested
+	maxKeySuggestionDistance = 2
+)
+
+// reservedKeys are the keys of the config data file sections that are used by Comic itself
+var reservedKeys = []string{requiredKey, aliasesKey, includeKey, extendsKey}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isReservedKey checks if the passed key belongs to a section used by Comic itself
+func isReservedKey(key string) bool {
+	rootKey := strings.SplitN(key, viperNestedKeySeparator, 2)[0]
+
+	for _, reservedKey := range reservedKeys {
+		if rootKey == reservedKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+// structField describes a leaf field of a config struct
+type structField struct {
+	// key of the config variable mapped to the field e.g. server.port
+	key string
+	// type of the field (dereferenced, if it's a pointer)
+	typ reflect.Type
+	// whether the field (or a field it's nested under) is tagged as required
+	required bool
+}
+
+// getStructKeys returns the keys of all the leaf fields of the passed struct (or pointer to it)
+// as derived from their mapstructure tags (or names) e.g. server.port
+func getStructKeys(cfg interface{}) (keys []string) {
+	for _, field := range getStructFields(cfg) {
+		keys = append(keys, field.key)
+	}
+
+	return
+}
+
+// getStructFields returns all the leaf fields of the passed struct (or pointer to it)
+func getStructFields(cfg interface{}) []structField {
+	return getTypeFields(reflect.TypeOf(cfg), "")
+}
+
+// getTypeFields returns all the leaf fields of the passed struct type, with keys prefixed with the passed prefix
+// a type that isn't a struct (or is unmarshalled from text e.g. time.Time) is a leaf itself
+func getTypeFields(t reflect.Type, prefix string) (fields []structField) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isLeafType(t) {
+		if prefix != "" {
+			fields = append(fields, structField{key: prefix, typ: t})
+		}
+
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tagParts := strings.Split(field.Tag.Get(mapstructureTagName), ",")
+
+		name := tagParts[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		var fieldFields []structField
+
+		if hasTagOption(tagParts[1:], "squash") {
+			fieldFields = getTypeFields(field.Type, prefix)
+		} else {
+			if prefix != "" {
+				name = prefix + viperNestedKeySeparator + name
+			}
+
+			fieldFields = getTypeFields(field.Type, strings.ToLower(name))
+		}
+
+		if hasTagOption(strings.Split(field.Tag.Get(comicTagName), ","), requiredTagOption) {
+			for j := range fieldFields {
+				fieldFields[j].required = true
+			}
+		}
+
+		fields = append(fields, fieldFields...)
+	}
+
+	return
+}
+
+// isLeafType checks if the passed (dereferenced) type has no fields of its own to be mapped to config variables
+// i.e. it isn't a struct or it's unmarshalled from text e.g. time.Time
+func isLeafType(t reflect.Type) bool {
+	return t == nil || t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// hasTagOption checks if the passed option is present in the passed struct tag options
+func hasTagOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suggestKey returns the known key nearest to the passed key, if any is near enough
+// otherwise, it returns an empty string
+func suggestKey(key string, knownKeys []string) (suggestion string) {
+	minDistance := maxKeySuggestionDistance + 1
+
+	for _, knownKey := range knownKeys {
+		if distance := editDistance(key, knownKey); distance < minDistance && distance < len(key) {
+			minDistance = distance
+			suggestion = knownKey
+		}
+	}
+
+	return
+}
+
+// editDistance returns the Levenshtein distance between the passed strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// minInt returns the smaller of the passed integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,179 @@
+package comic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type keysConfig struct {
+	Name   string `mapstructure:"NAME"`
+	Server struct {
+		Host string
+		Port int `mapstructure:"port"`
+	} `mapstructure:"SERVER"`
+	TTL      time.Duration `mapstructure:"TTL"`
+	StartAt  time.Time     `mapstructure:"start_at"`
+	DB       *keysDB       `mapstructure:"db"`
+	Embedded keysDB        `mapstructure:",squash"`
+	Labels   map[string]string
+	Ignored  string `mapstructure:"-"`
+	hidden   string
+}
+
+type keysDB struct {
+	Connections int `mapstructure:"connections"`
+}
+
+func TestIsReservedKey(t *testing.T) {
+	cases := []struct {
+		key      string
+		expected bool
+	}{
+		{
+			key:      "required",
+			expected: true,
+		},
+		{
+			key:      "required.run.name",
+			expected: true,
+		},
+		{
+			key:      "aliases.run",
+			expected: true,
+		},
+		{
+			key:      "include",
+			expected: true,
+		},
+		{
+			key:      "extends",
+			expected: true,
+		},
+		{
+			key:      "name",
+			expected: false,
+		},
+		{
+			key:      "server.required",
+			expected: false,
+		},
+		{
+			key:      "requirements",
+			expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, isReservedKey(c.key))
+	}
+}
+
+func TestGetStructKeys(t *testing.T) {
+	cases := []struct {
+		cfg      interface{}
+		expected []string
+	}{
+		{
+			cfg:      nil,
+			expected: nil,
+		},
+		{
+			cfg:      &sampleConfig{},
+			expected: nil,
+		},
+		{
+			cfg:      &keysDB{},
+			expected: []string{"connections"},
+		},
+		{
+			cfg: &keysConfig{},
+			expected: []string{
+				"name",
+				"server.host",
+				"server.port",
+				"ttl",
+				"start_at",
+				"db.connections",
+				"connections",
+				"labels",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, getStructKeys(c.cfg))
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	knownKeys := []string{"name", "server.host", "server.port", "ttl"}
+
+	cases := []struct {
+		key, expected string
+	}{
+		{
+			key:      "sever.port",
+			expected: "server.port",
+		},
+		{
+			key:      "server.prot",
+			expected: "server.port",
+		},
+		{
+			key:      "tll",
+			expected: "ttl",
+		},
+		{
+			key:      "database.url",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, suggestKey(c.key, knownKeys))
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{
+			a:        "",
+			b:        "",
+			expected: 0,
+		},
+		{
+			a:        "port",
+			b:        "",
+			expected: 4,
+		},
+		{
+			a:        "",
+			b:        "port",
+			expected: 4,
+		},
+		{
+			a:        "port",
+			b:        "port",
+			expected: 0,
+		},
+		{
+			a:        "sever",
+			b:        "server",
+			expected: 1,
+		},
+		{
+			a:        "kitten",
+			b:        "sitting",
+			expected: 3,
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, editDistance(c.a, c.b))
+	}
+}
@@ -0,0 +1,409 @@
+package comic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// SliceMergeReplace makes slices of upper config layers replace the ones of lower layers
+	SliceMergeReplace = "replace"
+	// SliceMergeAppend makes slices of upper config layers get appended to the ones of lower layers
+	SliceMergeAppend = "append"
+	// default name of the environment variable containing the profile
+	defaultProfileEnvVar = "COMIC_PROFILE"
+	// default strategy for merging slices of config layers
+	defaultSliceMerge = SliceMergeReplace
+	// name of the config layer of local overrides (e.g. config.local.yaml), loaded on top of all others
+	localLayerName = "local"
+)
+
+// readInConfig reads config data file along with its overlays (if any) into Viper
+// i.e. default config data file < config.yaml < config.<profile>.yaml < config.local.yaml, each from the directory of
+// config data file (or from each of the config file paths, if MergeConfigFiles is set)
+// where later layers are merged into earlier ones
+// config data file is the one set through ConfigFile (or ConfigFileEnvVar), if any, otherwise, it's searched for
+// an error (listing the config file paths, if searched for) is returned if config data file isn't found,
+// unless there is a default config data file, a remote config document or ConfigFileOptional is set
+// (in which case, it's left out)
+// the remote config document & the config directories (if any) are layered on top of all config data files
+func (c *Comic) readInConfig() error {
+	switch c.SliceMerge {
+	case "", SliceMergeReplace, SliceMergeAppend:
+	default:
+		return fmt.Errorf("slice merge strategy '%s' unknown", c.SliceMerge)
+	}
+
+	defaults, err := c.readDefaultConfig()
+	if err != nil {
+		return fmt.Errorf("default config not read: %s", err)
+	}
+
+	configFile, err := c.getConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if configFile != "" {
+		c.vip.SetConfigFile(configFile)
+	} else {
+		c.vip.SetConfigName(c.ConfigFileName)
+		for _, configFilePath := range c.getConfigFilePaths() {
+			c.vip.AddConfigPath(configFilePath)
+		}
+	}
+
+	found := true
+
+	if err := c.vip.ReadInConfig(); err != nil {
+		_, notFound := err.(viper.ConfigFileNotFoundError)
+		if configFile != "" {
+			notFound = os.IsNotExist(err)
+		}
+
+		switch {
+		case !notFound && configFile != "":
+			return fmt.Errorf("config file '%s' not read: %s", configFile, err)
+		case !notFound:
+			return err
+		case defaults == nil && c.RemoteConfigURL == "" && !c.ConfigFileOptional && configFile != "":
+			return fmt.Errorf("config file '%s' not found", configFile)
+		case defaults == nil && c.RemoteConfigURL == "" && !c.ConfigFileOptional:
+			return fmt.Errorf("config file '%s' not found in: %s", c.ConfigFileName, strings.Join(c.getConfigFilePaths(), ", "))
+		}
+
+		found = false
+	}
+
+	var layers []map[string]interface{}
+	var files []string
+
+	if found {
+		if layers, files, err = c.readLayers(configFile == ""); err != nil {
+			return err
+		}
+	}
+
+	c.configFiles = files
+
+	if defaults != nil {
+		layers = append([]map[string]interface{}{defaults}, layers...)
+	}
+
+	remoteSettings, err := c.readRemoteConfig()
+	if err != nil {
+		return fmt.Errorf("remote config not read: %s", err)
+	}
+
+	if remoteSettings != nil {
+		layers = append(layers, remoteSettings)
+	}
+
+	dirSettings, err := c.readConfigDirs()
+	if err != nil {
+		return fmt.Errorf("config dir not read: %s", err)
+	}
+
+	if dirSettings != nil {
+		layers = append(layers, dirSettings)
+	}
+
+	if found && len(layers) < 2 && len(files) < 2 {
+		return nil
+	}
+
+	settings := make(map[string]interface{})
+	for _, layer := range layers {
+		mergeSettings(settings, layer, c.SliceMerge)
+	}
+
+	return c.setConfig(settings)
+}
+
+// readConfig reads config data of the passed format from the passed reader into Viper
+// on top of the default config data file (if any) & beneath the config directories (if any)
+func (c *Comic) readConfig(r io.Reader, format string) error {
+	if !isSupportedConfigType(format) {
+		return fmt.Errorf("config type '%s' not supported", format)
+	}
+
+	defaults, err := c.readDefaultConfig()
+	if err != nil {
+		return fmt.Errorf("default config not read: %s", err)
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+
+	if err := v.ReadConfig(r); err != nil {
+		return err
+	}
+
+	settings := make(map[string]interface{})
+	if defaults != nil {
+		mergeSettings(settings, defaults, c.SliceMerge)
+	}
+
+	mergeSettings(settings, getRawSettings(v), c.SliceMerge)
+
+	dirSettings, err := c.readConfigDirs()
+	if err != nil {
+		return fmt.Errorf("config dir not read: %s", err)
+	}
+
+	if dirSettings != nil {
+		mergeSettings(settings, dirSettings, c.SliceMerge)
+	}
+
+	return c.setConfig(settings)
+}
+
+// readDefaultConfig returns the settings of the default config data file, if DefaultConfigFS is set
+// otherwise, it returns nil
+func (c *Comic) readDefaultConfig() (map[string]interface{}, error) {
+	if c.DefaultConfigFS == nil {
+		return nil, nil
+	}
+
+	name := c.DefaultConfigFile
+	if name == "" {
+		for _, ext := range viper.SupportedExts {
+			if _, err := fs.Stat(c.DefaultConfigFS, c.ConfigFileName+"."+ext); err == nil {
+				name = c.ConfigFileName + "." + ext
+				break
+			}
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("config file '%s' not found", c.ConfigFileName)
+		}
+	}
+
+	content, err := fs.ReadFile(c.DefaultConfigFS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	configType := strings.TrimPrefix(path.Ext(name), ".")
+	if !isSupportedConfigType(configType) {
+		return nil, fmt.Errorf("config type of %s not supported", name)
+	}
+
+	v := viper.New()
+	v.SetConfigType(configType)
+
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	return getRawSettings(v), nil
+}
+
+// isSupportedConfigType checks if the passed config type (i.e. file extension) is supported by Viper
+func isSupportedConfigType(configType string) bool {
+	for _, ext := range viper.SupportedExts {
+		if ext == configType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readLayers returns the settings of config data file & its overlays from the lowest to the highest precedence,
+// along with the paths of all files read (including the ones they include)
+// the overlays are looked for in the directory of config data file, unless it was searched for
+// and MergeConfigFiles is set (in which case, config data files & their overlays are looked for in all config file paths)
+func (c *Comic) readLayers(searched bool) (layers []map[string]interface{}, files []string, err error) {
+	configFile := c.vip.ConfigFileUsed()
+	if configFile == "" {
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+	overlayNames := c.getOverlayNames(name)
+
+	var layerFiles []string
+
+	if searched && c.MergeConfigFiles {
+		configFilePaths := c.getConfigFilePaths()
+
+		for i := len(configFilePaths) - 1; i >= 0; i-- {
+			layerFiles = append(layerFiles, findConfigFile(configFilePaths[i], name))
+			for _, overlayName := range overlayNames {
+				layerFiles = append(layerFiles, findConfigFile(configFilePaths[i], overlayName))
+			}
+		}
+	} else {
+		layerFiles = append(layerFiles, configFile)
+		for _, overlayName := range overlayNames {
+			layerFiles = append(layerFiles, findConfigFile(filepath.Dir(configFile), overlayName))
+		}
+	}
+
+	for _, layerFile := range layerFiles {
+		if layerFile == "" {
+			continue
+		}
+
+		layer, layerFilesRead, err := c.readConfigFile(layerFile, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		layers = append(layers, layer)
+		files = append(files, layerFilesRead...)
+	}
+
+	return
+}
+
+// getOverlayNames returns the names (without extension) of the overlays of the config data file of the passed name,
+// from the lowest to the highest precedence i.e. the one of the profile (if any) & the local one
+func (c *Comic) getOverlayNames(name string) (overlayNames []string) {
+	if profile := c.getProfile(); profile != "" {
+		overlayNames = append(overlayNames, name+"."+profile)
+	}
+
+	return append(overlayNames, name+"."+localLayerName)
+}
+
+// getConfigFile returns the absolute path of config data file, if set through ConfigFile
+// or the environment variable named ConfigFileEnvVar (the former takes precedence)
+// otherwise, it returns an empty string i.e. config data file is searched for in the config file paths
+func (c *Comic) getConfigFile() (string, error) {
+	configFile := c.ConfigFile
+	if configFile == "" {
+		configFile, _ = lookupEnvVar(c.ConfigFileEnvVar)
+	}
+
+	if configFile == "" {
+		return "", nil
+	}
+
+	return filepath.Abs(configFile)
+}
+
+// getConfigFilePaths returns the paths config data file is searched in, in order
+// i.e. ConfigFilePaths if set, otherwise, ConfigFilePath
+func (c *Comic) getConfigFilePaths() []string {
+	if len(c.ConfigFilePaths) > 0 {
+		return c.ConfigFilePaths
+	}
+
+	return []string{c.ConfigFilePath}
+}
+
+// DefaultSearchPaths returns the conventional paths of config data file of the passed application, in order
+// i.e. the working directory, $XDG_CONFIG_HOME/<app> (or $HOME/.config/<app>), $HOME/.<app> & /etc/<app>
+//
+// note: the paths depending on the home directory are left out if it's unknown
+func DefaultSearchPaths(appName string) []string {
+	paths := []string{defaultConfigFilePath}
+
+	home, _ := os.UserHomeDir()
+
+	if xdgConfigHome, ok := lookupEnvVar("XDG_CONFIG_HOME"); ok {
+		paths = append(paths, filepath.Join(xdgConfigHome, appName))
+	} else if home != "" {
+		paths = append(paths, filepath.Join(home, ".config", appName))
+	}
+
+	if home != "" {
+		paths = append(paths, filepath.Join(home, "."+appName))
+	}
+
+	return append(paths, filepath.Join("/etc", appName))
+}
+
+// getProfile returns Profile if set, otherwise, the value of the environment variable named ProfileEnvVar
+func (c *Comic) getProfile() string {
+	if c.Profile != "" {
+		return c.Profile
+	}
+
+	profile, _ := lookupEnvVar(c.ProfileEnvVar)
+
+	return profile
+}
+
+// findConfigFile returns the path of the config data file of the passed name (with any extension supported by Viper)
+// in the passed directory, if any
+// otherwise, it returns an empty string
+func findConfigFile(dir, name string) string {
+	for _, ext := range viper.SupportedExts {
+		file := filepath.Join(os.ExpandEnv(dir), name+"."+ext)
+
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+
+	return ""
+}
+
+// getRawSettings returns the config variables of the passed Viper instance (with no sources other than a config data file)
+// as nested maps
+// unlike AllSettings of Viper, keys without values (e.g. the ones in required sections) are kept
+func getRawSettings(v *viper.Viper) map[string]interface{} {
+	settings := make(map[string]interface{})
+
+	for _, key := range v.AllKeys() {
+		setNestedValue(settings, key, v.Get(key))
+	}
+
+	return settings
+}
+
+// mergeSettings merges the passed settings of an upper config layer into the passed settings of a lower layer
+// i.e. maps are merged recursively, slices are replaced or appended to (depending on the passed slice merge strategy)
+// and other values are replaced, unless the upper value is empty (e.g. a key in a required section)
+func mergeSettings(dst, src map[string]interface{}, sliceMerge string) {
+	for key, srcValue := range src {
+		dstValue, ok := dst[key]
+		if !ok {
+			dst[key] = srcValue
+			continue
+		}
+
+		if srcValue == nil {
+			continue
+		}
+
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dstValue.(map[string]interface{})
+
+		switch {
+		case srcIsMap && dstIsMap:
+			mergeSettings(dstMap, srcMap, sliceMerge)
+		case sliceMerge == SliceMergeAppend && isList(srcValue) && isList(dstValue):
+			dst[key] = append(toList(dstValue), toList(srcValue)...)
+		default:
+			dst[key] = srcValue
+		}
+	}
+}
+
+// isList checks if the passed value is a slice or an array
+func isList(value interface{}) bool {
+	kind := reflect.ValueOf(value).Kind()
+
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// setConfig replaces the config data of Viper with the passed settings
+func (c *Comic) setConfig(settings map[string]interface{}) error {
+	// reading an empty config resets the config data of Viper, even if the config type deems it invalid (e.g. JSON)
+	_ = c.vip.ReadConfig(strings.NewReader(""))
+
+	return c.vip.MergeConfigMap(settings)
+}
@@ -0,0 +1,475 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type layeredConfig struct {
+	Name    string   `mapstructure:"NAME"`
+	Origins []string `mapstructure:"ORIGINS"`
+	Server  struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	} `mapstructure:"SERVER"`
+}
+
+func TestMergeSettings(t *testing.T) {
+	cases := []struct {
+		dst, src   map[string]interface{}
+		sliceMerge string
+		expected   map[string]interface{}
+	}{
+		{
+			dst:        map[string]interface{}{"a": 1, "b": 2},
+			src:        map[string]interface{}{"b": "3", "c": 4},
+			sliceMerge: SliceMergeReplace,
+			expected:   map[string]interface{}{"a": 1, "b": "3", "c": 4},
+		},
+		{
+			dst:        map[string]interface{}{"a": 1},
+			src:        map[string]interface{}{"a": nil, "b": nil},
+			sliceMerge: SliceMergeReplace,
+			expected:   map[string]interface{}{"a": 1, "b": nil},
+		},
+		{
+			dst:        map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}},
+			src:        map[string]interface{}{"a": map[string]interface{}{"c": 3, "d": 4}},
+			sliceMerge: SliceMergeReplace,
+			expected:   map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 3, "d": 4}},
+		},
+		{
+			dst:        map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			src:        map[string]interface{}{"a": "b"},
+			sliceMerge: SliceMergeReplace,
+			expected:   map[string]interface{}{"a": "b"},
+		},
+		{
+			dst:        map[string]interface{}{"a": []interface{}{1, 2}},
+			src:        map[string]interface{}{"a": []interface{}{3}},
+			sliceMerge: SliceMergeReplace,
+			expected:   map[string]interface{}{"a": []interface{}{3}},
+		},
+		{
+			dst:        map[string]interface{}{"a": []interface{}{1, 2}},
+			src:        map[string]interface{}{"a": []interface{}{3}},
+			sliceMerge: SliceMergeAppend,
+			expected:   map[string]interface{}{"a": []interface{}{1, 2, 3}},
+		},
+		{
+			dst:        map[string]interface{}{"a": "1,2"},
+			src:        map[string]interface{}{"a": []interface{}{3}},
+			sliceMerge: SliceMergeAppend,
+			expected:   map[string]interface{}{"a": []interface{}{3}},
+		},
+	}
+
+	for _, c := range cases {
+		mergeSettings(c.dst, c.src, c.sliceMerge)
+
+		assert.Equal(t, c.expected, c.dst)
+	}
+}
+
+func TestComic_LoadForCommand_layers(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: app
+origins: [a]
+server:
+  host: localhost
+  port: 80
+required:
+  main:
+    name:
+`,
+		"config.production.yaml": `
+origins: [b]
+server:
+  host: remote
+required:
+  main:
+    server.host:
+  run:
+    name:
+`,
+		"config.local.json": `{"server": {"port": "8080"}}`,
+	})
+	defer remove()
+
+	cases := []struct {
+		opts           Options
+		env            map[string]string
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{ConfigFilePath: dir},
+			expectedOutput: &layeredConfig{
+				Name:    "app",
+				Origins: []string{"a"},
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 8080},
+			},
+		},
+		{
+			opts: Options{ConfigFilePath: dir, Profile: "production"},
+			expectedOutput: &layeredConfig{
+				Name:    "app",
+				Origins: []string{"b"},
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"remote", 8080},
+			},
+		},
+		{
+			opts: Options{ConfigFilePath: dir, SliceMerge: SliceMergeAppend},
+			env:  map[string]string{"COMIC_PROFILE": "production"},
+			expectedOutput: &layeredConfig{
+				Name:    "app",
+				Origins: []string{"a", "b"},
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"remote", 8080},
+			},
+		},
+		{
+			opts: Options{ConfigFilePath: dir, ProfileEnvVar: "APP_ENV"},
+			env:  map[string]string{"APP_ENV": "staging"},
+			expectedOutput: &layeredConfig{
+				Name:    "app",
+				Origins: []string{"a"},
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 8080},
+			},
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, SliceMerge: "merge"},
+			expectedOutput: &layeredConfig{},
+			expectedError:  errors.New("config not loaded: slice merge strategy 'merge' unknown"),
+		},
+	}
+
+	for _, c := range cases {
+		unset := setEnvVars(c.env)
+
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		unset()
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_layeredRequiredSections(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: app
+required:
+  main:
+    name:
+`,
+		"config.production.yaml": `
+required:
+  main:
+    server.host:
+`,
+	})
+	defer remove()
+
+	err := NewWithOptions(Options{ConfigFilePath: dir, Profile: "production"}).Load(&layeredConfig{})
+
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: server.host"), err)
+}
+
+func TestDefaultSearchPaths(t *testing.T) {
+	cases := []struct {
+		env      map[string]string
+		expected []string
+	}{
+		{
+			env:      map[string]string{"HOME": "/home/me", "XDG_CONFIG_HOME": "/xdg"},
+			expected: []string{".", "/xdg/app", "/home/me/.app", "/etc/app"},
+		},
+		{
+			env:      map[string]string{"HOME": "/home/me", "XDG_CONFIG_HOME": ""},
+			expected: []string{".", "/home/me/.config/app", "/home/me/.app", "/etc/app"},
+		},
+	}
+
+	for _, c := range cases {
+		original := map[string]string{"HOME": os.Getenv("HOME"), "XDG_CONFIG_HOME": os.Getenv("XDG_CONFIG_HOME")}
+		setEnvVars(c.env)
+
+		assert.Equal(t, c.expected, DefaultSearchPaths("app"))
+
+		setEnvVars(original)
+	}
+}
+
+func TestComic_LoadForCommand_searchPaths(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"home/config.yaml": `
+name: home
+server:
+  host: localhost
+`,
+		"etc/config.yaml": `
+name: etc
+server:
+  port: 80
+`,
+		"etc/config.local.yaml": `
+server:
+  host: remote
+`,
+	})
+	defer remove()
+
+	home, etc, missing := filepath.Join(dir, "home"), filepath.Join(dir, "etc"), filepath.Join(dir, "missing")
+
+	cases := []struct {
+		opts           Options
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{ConfigFilePaths: []string{missing, home, etc}},
+			expectedOutput: &layeredConfig{
+				Name: "home",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 0},
+			},
+		},
+		{
+			opts: Options{ConfigFilePaths: []string{missing, home, etc}, MergeConfigFiles: true},
+			expectedOutput: &layeredConfig{
+				Name: "home",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 80},
+			},
+		},
+		{
+			opts: Options{ConfigFilePaths: []string{etc, home}, MergeConfigFiles: true},
+			expectedOutput: &layeredConfig{
+				Name: "etc",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"remote", 80},
+			},
+		},
+		{
+			opts:           Options{ConfigFilePaths: []string{missing, "/nonexistent"}},
+			expectedOutput: &layeredConfig{},
+			expectedError:  fmt.Errorf("config not loaded: config file 'config' not found in: %s, /nonexistent", missing),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_defaultConfig(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"disk/config.yaml": `
+server:
+  host: remote
+`,
+	})
+	defer remove()
+
+	defaultFS := fstest.MapFS{
+		"config.yaml": {Data: []byte(`
+name: app
+server:
+  host: localhost
+  port: 80
+required:
+  main:
+    name:
+    server.port:
+`)},
+		"defaults/app.json": {Data: []byte(`{"name": "json"}`)},
+		"defaults/app.txt":  {Data: []byte(`name=txt`)},
+	}
+
+	cases := []struct {
+		opts           Options
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{ConfigFilePath: filepath.Join(dir, "disk"), DefaultConfigFS: defaultFS},
+			expectedOutput: &layeredConfig{
+				Name: "app",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"remote", 80},
+			},
+		},
+		{
+			opts: Options{ConfigFilePath: dir, DefaultConfigFS: defaultFS},
+			expectedOutput: &layeredConfig{
+				Name: "app",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 80},
+			},
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, DefaultConfigFS: defaultFS, DefaultConfigFile: "defaults/app.json"},
+			expectedOutput: &layeredConfig{Name: "json"},
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, DefaultConfigFS: defaultFS, DefaultConfigFile: "defaults/app.txt"},
+			expectedOutput: &layeredConfig{},
+			expectedError:  errors.New("config not loaded: default config not read: config type of defaults/app.txt not supported"),
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, DefaultConfigFS: defaultFS, ConfigFileName: "app"},
+			expectedOutput: &layeredConfig{},
+			expectedError:  errors.New("config not loaded: default config not read: config file 'app' not found"),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_configFile(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"prod.yml": `
+name: prod
+server:
+  host: localhost
+`,
+		"prod.local.yaml": `
+server:
+  port: 8080
+`,
+		"config.yaml": `
+name: config
+`,
+		"malformed.yaml": "name: [",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"APP_CONFIG": filepath.Join(dir, "prod.yml"),
+	})
+	defer unset()
+
+	cases := []struct {
+		opts           Options
+		expectedOutput *layeredConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{ConfigFilePath: dir, ConfigFileEnvVar: "APP_CONFIG"},
+			expectedOutput: &layeredConfig{
+				Name: "prod",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{"localhost", 8080},
+			},
+		},
+		{
+			opts:           Options{ConfigFile: filepath.Join(dir, "config.yaml"), ConfigFileEnvVar: "APP_CONFIG"},
+			expectedOutput: &layeredConfig{Name: "config"},
+		},
+		{
+			opts:           Options{ConfigFilePath: dir, ConfigFileEnvVar: "OTHER_CONFIG"},
+			expectedOutput: &layeredConfig{Name: "config"},
+		},
+		{
+			opts:           Options{ConfigFile: filepath.Join(dir, "missing.yaml")},
+			expectedOutput: &layeredConfig{},
+			expectedError:  fmt.Errorf("config not loaded: config file '%s' not found", filepath.Join(dir, "missing.yaml")),
+		},
+		{
+			opts:           Options{ConfigFile: filepath.Join(dir, "missing.yaml"), ConfigFileOptional: true},
+			expectedOutput: &layeredConfig{},
+		},
+		{
+			opts:           Options{ConfigFile: filepath.Join(dir, "malformed.yaml")},
+			expectedOutput: &layeredConfig{},
+			expectedError: fmt.Errorf("config not loaded: config file '%s' not read: While parsing config: yaml: line 1: did not find expected node content",
+				filepath.Join(dir, "malformed.yaml")),
+		},
+	}
+
+	for _, c := range cases {
+		cfg := &layeredConfig{}
+		err := NewWithOptions(c.opts).Load(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_getConfigFile(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	unset := setEnvVars(map[string]string{
+		"APP_CONFIG": "app.yaml",
+	})
+	defer unset()
+
+	cases := []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			opts:     Options{},
+			expected: "",
+		},
+		{
+			opts:     Options{ConfigFileEnvVar: "APP_CONFIG"},
+			expected: filepath.Join(wd, "app.yaml"),
+		},
+		{
+			opts:     Options{ConfigFile: "/etc/app/prod.yml", ConfigFileEnvVar: "APP_CONFIG"},
+			expected: "/etc/app/prod.yml",
+		},
+	}
+
+	for _, c := range cases {
+		configFile, err := (&Comic{Options: c.opts}).getConfigFile()
+
+		assert.Equal(t, c.expected, configFile)
+		assert.NoError(t, err)
+	}
+}
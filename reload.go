@@ -0,0 +1,110 @@
+package comic
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+)
+
+// ReloadResult is the result of loading config again
+type ReloadResult struct {
+	// Config is the newly loaded config struct (nil, on failure)
+	Config interface{}
+	// Err is the failure, if any
+	Err error
+}
+
+// Reloader loads config again whenever the process receives SIGHUP (see ReloadOnSIGHUP)
+type Reloader struct {
+	config  atomic.Value
+	results chan ReloadResult
+}
+
+// Config returns the last successfully loaded config struct
+// it's safe to call from multiple goroutines
+func (r *Reloader) Config() interface{} {
+	return r.config.Load()
+}
+
+// Results returns the channel the result of each reload is sent to
+// results are dropped if the channel isn't drained (i.e. only the latest unread one is kept)
+// the channel is closed once the reloader shuts down
+func (r *Reloader) Results() <-chan ReloadResult {
+	return r.results
+}
+
+// ReloadOnSIGHUP loads config for the passed command into the passed struct (as LoadForCommand does),
+// then loads config again into a new struct whenever the process receives SIGHUP, until the passed context is done
+// each new struct becomes the config of the returned reloader only if it's loaded successfully
+// & the result is passed to the passed function (if not nil) & sent to the channel of the reloader
+// an error is returned if config can't be loaded initially
+//
+// note: cfg *must* be a pointer
+// note: config must not be loaded through the same instance of Comic while reloading
+func ReloadOnSIGHUP(ctx context.Context, cfg interface{}, commandName string, onReload func(cfg interface{}, err error)) (*Reloader, error) {
+	return c.ReloadOnSIGHUP(ctx, cfg, commandName, onReload)
+}
+func (c *Comic) ReloadOnSIGHUP(ctx context.Context, cfg interface{}, commandName string, onReload func(cfg interface{}, err error)) (*Reloader, error) {
+	return c.reloadOnSignals(ctx, cfg, commandName, onReload, syscall.SIGHUP)
+}
+
+// reloadOnSignals loads config as ReloadOnSIGHUP does, on any of the passed signals
+func (c *Comic) reloadOnSignals(ctx context.Context, cfg interface{}, commandName string, onReload func(cfg interface{}, err error),
+	signals ...os.Signal) (*Reloader, error) {
+	if err := c.LoadForCommand(cfg, commandName); err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{results: make(chan ReloadResult, 1)}
+	r.config.Store(cfg)
+
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+
+	go func() {
+		defer close(r.results)
+		defer signal.Stop(received)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-received:
+			}
+
+			result := ReloadResult{Config: reflect.New(reflect.TypeOf(cfg).Elem()).Interface()}
+			if result.Err = c.LoadForCommand(result.Config, commandName); result.Err != nil {
+				result.Config = nil
+			} else {
+				r.config.Store(result.Config)
+			}
+
+			if onReload != nil {
+				onReload(result.Config, result.Err)
+			}
+
+			r.publish(result)
+		}
+	}()
+
+	return r, nil
+}
+
+// publish sends the passed result to the channel of the reloader, replacing the unread result (if any)
+func (r *Reloader) publish(result ReloadResult) {
+	for {
+		select {
+		case r.results <- result:
+			return
+		default:
+		}
+
+		select {
+		case <-r.results:
+		default:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package comic
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nextReloadResult returns the next result of the passed reloader, failing the test if there is none in time
+func nextReloadResult(t *testing.T, r *Reloader) ReloadResult {
+	select {
+	case result := <-r.Results():
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("config not reloaded")
+	}
+
+	return ReloadResult{}
+}
+
+func TestComic_ReloadOnSIGHUP(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: a
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	write := func(content string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644))
+	}
+
+	hangUp := func() {
+		process, err := os.FindProcess(os.Getpid())
+		assert.NoError(t, err)
+		assert.NoError(t, process.Signal(syscall.SIGHUP))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var reloaded []error
+
+	cfg := &layeredConfig{}
+	r, err := NewWithOptions(Options{ConfigFilePath: dir}).ReloadOnSIGHUP(ctx, cfg, "main", func(cfg interface{}, err error) {
+		reloaded = append(reloaded, err)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, cfg, r.Config())
+
+	write("name: b\nrequired:\n  main:\n    name:\n")
+	hangUp()
+
+	result := nextReloadResult(t, r)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, &layeredConfig{Name: "b"}, result.Config)
+	assert.Equal(t, result.Config, r.Config())
+
+	write("required:\n  main:\n    name:\n")
+	hangUp()
+
+	result = nextReloadResult(t, r)
+	assert.Nil(t, result.Config)
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), result.Err)
+	assert.Equal(t, &layeredConfig{Name: "b"}, r.Config())
+
+	assert.Equal(t, []error{nil, result.Err}, reloaded)
+	assert.Equal(t, "a", cfg.Name)
+
+	cancel()
+
+	select {
+	case _, ok := <-r.Results():
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Error("reloader not shut down")
+	}
+}
+
+func TestComic_ReloadOnSIGHUP_invalidConfig(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	r, err := NewWithOptions(Options{ConfigFilePath: dir}).ReloadOnSIGHUP(context.Background(), &layeredConfig{}, "main", nil)
+
+	assert.Nil(t, r)
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), err)
+}
+
+func TestReloader_publish(t *testing.T) {
+	r := &Reloader{results: make(chan ReloadResult, 1)}
+
+	r.publish(ReloadResult{Err: errors.New("a")})
+	r.publish(ReloadResult{Err: errors.New("b")})
+
+	assert.Equal(t, ReloadResult{Err: errors.New("b")}, <-r.Results())
+}
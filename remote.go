@@ -0,0 +1,197 @@
+package comic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// timeout of requests for the remote config document, unless RemoteConfigClient is set
+	defaultRemoteConfigTimeout = 10 * time.Second
+	// suffix of the name of the file the ETag of the cached copy of the remote config document is stored in
+	remoteConfigETagFileSuffix = ".etag"
+)
+
+// readRemoteConfig returns the settings of the remote config document, if RemoteConfigURL is set
+// otherwise, it returns nil
+func (c *Comic) readRemoteConfig() (map[string]interface{}, error) {
+	if c.RemoteConfigURL == "" {
+		return nil, nil
+	}
+
+	configType, err := c.getRemoteConfigType()
+	if err != nil {
+		return nil, err
+	}
+
+	// the cached copy (if any) is used when the document can't be fetched
+	content, _, err := c.fetchRemoteConfig(context.Background())
+	if content == nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigType(configType)
+
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+
+	return getRawSettings(v), nil
+}
+
+// getRemoteConfigType returns RemoteConfigFormat if set, otherwise, the extension of the path of RemoteConfigURL
+// an error is returned if the config type isn't supported by Viper
+func (c *Comic) getRemoteConfigType() (string, error) {
+	configType := c.RemoteConfigFormat
+
+	if configType == "" {
+		u, err := url.Parse(c.RemoteConfigURL)
+		if err != nil {
+			return "", err
+		}
+
+		configType = strings.TrimPrefix(path.Ext(u.Path), ".")
+	}
+
+	if !isSupportedConfigType(configType) {
+		return "", fmt.Errorf("config type '%s' not supported", configType)
+	}
+
+	return configType, nil
+}
+
+// fetchRemoteConfig returns the remote config document, fetched from RemoteConfigURL (until the passed context is done),
+// along with whether it changed since the last time it was fetched
+// the last fetched copy (kept in memory & in RemoteConfigCacheFile, if set) is returned if the document didn't change
+// (i.e. according to its ETag), and along with the error if the endpoint can't be reached (or fails)
+func (c *Comic) fetchRemoteConfig(ctx context.Context) (content []byte, changed bool, err error) {
+	c.remoteMutex.Lock()
+	defer c.remoteMutex.Unlock()
+
+	cached, etag := c.remoteContent, c.remoteETag
+	if cached == nil {
+		cached, etag = c.readRemoteConfigCache()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.RemoteConfigURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if cached != nil && etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client := c.RemoteConfigClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultRemoteConfigTimeout}
+	}
+
+	fallBack := func(err error) ([]byte, bool, error) {
+		if cached != nil {
+			c.remoteContent, c.remoteETag = cached, etag
+		}
+
+		return cached, false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fallBack(err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return fallBack(nil)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fallBack(fmt.Errorf("%s: %s", c.RemoteConfigURL, resp.Status))
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("%s: %s", c.RemoteConfigURL, resp.Status)
+	}
+
+	if content, err = ioutil.ReadAll(resp.Body); err != nil {
+		return nil, false, err
+	}
+
+	changed = c.remoteContent != nil && !bytes.Equal(content, c.remoteContent)
+	c.remoteContent, c.remoteETag = content, resp.Header.Get("ETag")
+
+	if err := c.writeRemoteConfigCache(); err != nil {
+		return nil, false, fmt.Errorf("cache not written: %s", err)
+	}
+
+	return content, changed, nil
+}
+
+// readRemoteConfigCache returns the cached copy of the remote config document along with its ETag,
+// if RemoteConfigCacheFile is set & exists
+// otherwise, it returns nil and an empty string
+func (c *Comic) readRemoteConfigCache() ([]byte, string) {
+	if c.RemoteConfigCacheFile == "" {
+		return nil, ""
+	}
+
+	content, err := ioutil.ReadFile(c.RemoteConfigCacheFile)
+	if err != nil {
+		return nil, ""
+	}
+
+	etag, _ := ioutil.ReadFile(c.RemoteConfigCacheFile + remoteConfigETagFileSuffix)
+
+	return content, strings.TrimSpace(string(etag))
+}
+
+// writeRemoteConfigCache writes the last fetched copy of the remote config document along with its ETag
+// into RemoteConfigCacheFile, if set
+func (c *Comic) writeRemoteConfigCache() error {
+	if c.RemoteConfigCacheFile == "" {
+		return nil
+	}
+
+	if err := ioutil.WriteFile(c.RemoteConfigCacheFile, c.remoteContent, 0600); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.RemoteConfigCacheFile+remoteConfigETagFileSuffix, []byte(c.remoteETag), 0600)
+}
+
+// PollRemoteConfig fetches the remote config document every passed interval (with If-None-Match)
+// until the passed context is done (cancelling the request in flight), calling the passed function with nil
+// whenever the document changed (i.e. config should be loaded again) or with the error whenever it failed to be fetched
+// (even though loading config falls back to the cached copy)
+//
+// note: it blocks, so it's meant to be called in a goroutine
+func PollRemoteConfig(ctx context.Context, interval time.Duration, onChange func(err error)) {
+	c.PollRemoteConfig(ctx, interval, onChange)
+}
+func (c *Comic) PollRemoteConfig(ctx context.Context, interval time.Duration, onChange func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, changed, err := c.fetchRemoteConfig(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil || changed {
+				onChange(err)
+			}
+		}
+	}
+}
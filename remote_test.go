@@ -0,0 +1,278 @@
+package comic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// remoteConfigServer serves a config document with an ETag (or fails with status, if set),
+// recording the If-None-Match headers of requests
+type remoteConfigServer struct {
+	sync.Mutex
+	content, etag string
+	status        int
+	ifNoneMatches []string
+}
+
+func (s *remoteConfigServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.ifNoneMatches = append(s.ifNoneMatches, r.Header.Get("If-None-Match"))
+
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	if r.Header.Get("If-None-Match") == s.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", s.etag)
+	fmt.Fprint(w, s.content)
+}
+
+func (s *remoteConfigServer) set(content, etag string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.content, s.etag = content, etag
+}
+
+func (s *remoteConfigServer) fail(status int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.status = status
+}
+
+func TestComic_LoadForCommand_remoteConfig(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: file
+server:
+  host: localhost
+required:
+  main:
+    server.port:
+`,
+	})
+	defer remove()
+
+	remoteServer := &remoteConfigServer{content: "server:\n  port: 8080\n", etag: `"v1"`}
+	server := httptest.NewServer(remoteServer)
+
+	cacheFile := filepath.Join(dir, "remote.yaml")
+	comic := NewWithOptions(Options{ConfigFilePath: dir, RemoteConfigURL: server.URL + "/myapp.yaml", RemoteConfigCacheFile: cacheFile})
+
+	expected := &layeredConfig{
+		Name: "file",
+		Server: struct {
+			Host string `mapstructure:"HOST"`
+			Port int    `mapstructure:"PORT"`
+		}{"localhost", 8080},
+	}
+
+	for i := 0; i < 2; i++ {
+		cfg := &layeredConfig{}
+
+		assert.NoError(t, comic.Load(cfg))
+		assert.Equal(t, expected, cfg)
+	}
+
+	assert.Equal(t, []string{"", `"v1"`}, remoteServer.ifNoneMatches)
+
+	cached, err := ioutil.ReadFile(cacheFile)
+	assert.NoError(t, err)
+	assert.Equal(t, remoteServer.content, string(cached))
+
+	server.Close()
+
+	// the cached copy is used once the endpoint can't be reached, even by a new instance
+	cfg := &layeredConfig{}
+	comic = NewWithOptions(Options{ConfigFilePath: dir, RemoteConfigURL: server.URL + "/myapp.yaml", RemoteConfigCacheFile: cacheFile})
+
+	assert.NoError(t, comic.Load(cfg))
+	assert.Equal(t, expected, cfg)
+
+	// without a cached copy, loading fails
+	cfg = &layeredConfig{}
+	comic = NewWithOptions(Options{ConfigFilePath: dir, RemoteConfigURL: server.URL + "/myapp.yaml"})
+
+	assert.Error(t, comic.Load(cfg))
+	assert.Equal(t, &layeredConfig{}, cfg)
+}
+
+func TestComic_getRemoteConfigType(t *testing.T) {
+	cases := []struct {
+		opts           Options
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			opts:           Options{RemoteConfigURL: "https://config.example.com/myapp.json?v=1"},
+			expectedOutput: "json",
+		},
+		{
+			opts:           Options{RemoteConfigURL: "https://config.example.com/myapp", RemoteConfigFormat: "yaml"},
+			expectedOutput: "yaml",
+		},
+		{
+			opts:          Options{RemoteConfigURL: "https://config.example.com/myapp"},
+			expectedError: errors.New("config type '' not supported"),
+		},
+	}
+
+	for _, c := range cases {
+		configType, err := (&Comic{Options: c.opts}).getRemoteConfigType()
+
+		assert.Equal(t, c.expectedOutput, configType)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_fetchRemoteConfig(t *testing.T) {
+	remoteServer := &remoteConfigServer{content: "name: a", etag: `"a"`}
+	server := httptest.NewServer(remoteServer)
+	defer server.Close()
+
+	comic := &Comic{Options: Options{RemoteConfigURL: server.URL}}
+
+	cases := []struct {
+		content, etag   string
+		expectedContent string
+		expectedChanged bool
+	}{
+		{
+			content:         "name: a",
+			etag:            `"a"`,
+			expectedContent: "name: a",
+			expectedChanged: false,
+		},
+		{
+			content:         "name: a",
+			etag:            `"a"`,
+			expectedContent: "name: a",
+			expectedChanged: false,
+		},
+		{
+			content:         "name: b",
+			etag:            `"b"`,
+			expectedContent: "name: b",
+			expectedChanged: true,
+		},
+	}
+
+	for _, c := range cases {
+		remoteServer.set(c.content, c.etag)
+
+		content, changed, err := comic.fetchRemoteConfig(context.Background())
+
+		assert.Equal(t, c.expectedContent, string(content))
+		assert.Equal(t, c.expectedChanged, changed)
+		assert.NoError(t, err)
+	}
+
+	// failures are returned along with the cached copy
+	remoteServer.fail(http.StatusInternalServerError)
+
+	content, changed, err := comic.fetchRemoteConfig(context.Background())
+	assert.Equal(t, "name: b", string(content))
+	assert.False(t, changed)
+	assert.Equal(t, fmt.Errorf("%s: 500 Internal Server Error", server.URL), err)
+
+	server.Close()
+
+	content, changed, err = comic.fetchRemoteConfig(context.Background())
+	assert.Equal(t, "name: b", string(content))
+	assert.False(t, changed)
+	assert.Error(t, err)
+}
+
+func TestComic_PollRemoteConfig(t *testing.T) {
+	remoteServer := &remoteConfigServer{content: "name: a", etag: `"a"`}
+	server := httptest.NewServer(remoteServer)
+	defer server.Close()
+
+	comic := &Comic{Options: Options{RemoteConfigURL: server.URL}}
+
+	_, _, err := comic.fetchRemoteConfig(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan error, 1)
+
+	done := make(chan struct{})
+	go func() {
+		comic.PollRemoteConfig(ctx, time.Millisecond, func(err error) { changes <- err })
+		close(done)
+	}()
+
+	remoteServer.set("name: b", `"b"`)
+
+	select {
+	case err := <-changes:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Error("change not detected")
+	}
+
+	remoteServer.fail(http.StatusInternalServerError)
+
+	select {
+	case err := <-changes:
+		assert.Equal(t, fmt.Errorf("%s: 500 Internal Server Error", server.URL), err)
+	case <-time.After(5 * time.Second):
+		t.Error("failure not reported")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestComic_PollRemoteConfig_cancel(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	comic := &Comic{Options: Options{RemoteConfigURL: server.URL}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan error, 1)
+
+	done := make(chan struct{})
+	go func() {
+		comic.PollRemoteConfig(ctx, time.Millisecond, func(err error) { changes <- err })
+		close(done)
+	}()
+
+	<-requested
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("request in flight not cancelled")
+	}
+
+	assert.Empty(t, changes)
+}
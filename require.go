@@ -0,0 +1,31 @@
+package comic
+
+import "strings"
+
+// Require registers the passed keys as required config variables of the passed command name
+// in addition to the ones declared in config data file
+// e.g. Require("run", "server.port") is the same as declaring required.run.server.port in config data file
+//
+// note: this allows verifying required config variables even without a config data file (see ConfigFileOptional)
+func Require(commandName string, keys ...string) { c.Require(commandName, keys...) }
+func (c *Comic) Require(commandName string, keys ...string) {
+	if c.requirements == nil {
+		c.requirements = make(map[string][]string)
+	}
+
+	for _, key := range keys {
+		c.requirements[commandName] = append(c.requirements[commandName], strings.ToLower(key))
+	}
+}
+
+// getRequiredStructKeys returns the keys of all the leaf fields of the passed struct tagged as required
+// (i.e. `comic:"required"`), directly or through a field they're nested under
+func getRequiredStructKeys(cfg interface{}) (keys []string) {
+	for _, field := range getStructFields(cfg) {
+		if field.required {
+			keys = append(keys, field.key)
+		}
+	}
+
+	return
+}
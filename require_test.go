@@ -0,0 +1,88 @@
+package comic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requiredConfig struct {
+	Name   string `mapstructure:"NAME" comic:"required"`
+	Server struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	} `mapstructure:"SERVER" comic:"required"`
+	Debug bool `mapstructure:"DEBUG"`
+}
+
+func TestRequire(t *testing.T) {
+	defer func(original *Comic) { c = original }(c)
+	c = &Comic{}
+
+	Require("serve", "port")
+
+	assert.Equal(t, map[string][]string{"serve": {"port"}}, c.requirements)
+}
+
+func TestComic_Require(t *testing.T) {
+	c := &Comic{}
+
+	c.Require("serve", "port", "Server.Host")
+	c.Require("run job", "name")
+	c.Require("serve", "name")
+
+	assert.Equal(t, map[string][]string{"serve": {"port", "server.host", "name"}, "run job": {"name"}}, c.requirements)
+}
+
+func TestGetRequiredStructKeys(t *testing.T) {
+	assert.Equal(t, []string{"name", "server.host", "server.port"}, getRequiredStructKeys(&requiredConfig{}))
+	assert.Nil(t, getRequiredStructKeys(&strictConfig{}))
+	assert.Nil(t, getRequiredStructKeys(nil))
+}
+
+func TestComic_LoadForCommand_optionalConfigFile(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"malformed/config.yaml": "name: [",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"NAME":        "app",
+		"SERVER_HOST": "localhost",
+	})
+	defer unset()
+
+	cases := []struct {
+		opts          Options
+		requirements  map[string][]string
+		expectedError error
+	}{
+		{
+			opts:          Options{ConfigFilePath: dir},
+			expectedError: errors.New("config not loaded: config file 'config' not found in: " + dir),
+		},
+		{
+			opts:          Options{ConfigFilePath: dir, ConfigFileOptional: true},
+			expectedError: errors.New("required config for command 'main' missing: config not present: server.port"),
+		},
+		{
+			opts:          Options{ConfigFilePath: dir, ConfigFileOptional: true, StrictCommands: true},
+			requirements:  map[string][]string{"main": {"debug"}},
+			expectedError: errors.New("required config for command 'main' missing: config not present: debug"),
+		},
+		{
+			opts:          Options{ConfigFilePath: dir + "/malformed", ConfigFileOptional: true},
+			expectedError: errors.New("config not loaded: While parsing config: yaml: line 1: did not find expected node content"),
+		},
+	}
+
+	for _, c := range cases {
+		comic := NewWithOptions(c.opts)
+		comic.requirements = c.requirements
+
+		err := comic.Load(&requiredConfig{})
+
+		assert.Equal(t, c.expectedError, err)
+	}
+}
@@ -0,0 +1,135 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// prefix of config values that are references to be resolved e.g. ref+file:///run/secrets/db
+const refPrefix = "ref+"
+
+// Resolver resolves references of a scheme (e.g. file in ref+file:///run/secrets/db) to values
+type Resolver interface {
+	Resolve(ref *url.URL) (string, error)
+}
+
+// ResolverFunc allows the use of ordinary functions as resolvers
+type ResolverFunc func(ref *url.URL) (string, error)
+
+// Resolve calls f(ref)
+func (f ResolverFunc) Resolve(ref *url.URL) (string, error) {
+	return f(ref)
+}
+
+// ResolverError describes a failure to resolve the reference of a config variable
+type ResolverError struct {
+	// Key of the config variable
+	Key string
+	// Ref is the reference (without the ref+ prefix) e.g. file:///run/secrets/db
+	Ref string
+	// Err is the failure
+	Err error
+}
+
+func (e *ResolverError) Error() string {
+	return fmt.Sprintf("config '%s' not resolved from %s: %s", e.Key, e.Ref, e.Err)
+}
+
+func (e *ResolverError) Unwrap() error {
+	return e.Err
+}
+
+// defaultResolvers are the resolvers available to all instances of Comic
+var defaultResolvers = map[string]Resolver{
+	"env":  ResolverFunc(resolveEnvRef),
+	"file": ResolverFunc(resolveFileRef),
+}
+
+// RegisterResolver registers the passed resolver for references of the passed scheme
+// replacing the default (or previously registered) resolver of the scheme, if any
+func RegisterResolver(scheme string, r Resolver) { c.RegisterResolver(scheme, r) }
+func (c *Comic) RegisterResolver(scheme string, r Resolver) {
+	if c.resolvers == nil {
+		c.resolvers = make(map[string]Resolver)
+	}
+
+	c.resolvers[strings.ToLower(scheme)] = r
+}
+
+// resolveRefs overrides all config variables (excluding the sections used by Comic itself)
+// that are references with the values they resolve to
+// a ResolverError is returned if a reference can't be resolved
+func (c *Comic) resolveRefs() error {
+	keys := c.vip.AllKeys()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if isReservedKey(key) {
+			continue
+		}
+
+		value, ok := c.vip.Get(key).(string)
+		if !ok || !strings.HasPrefix(value, refPrefix) {
+			continue
+		}
+
+		ref := strings.TrimPrefix(value, refPrefix)
+
+		resolved, err := c.resolveRef(ref)
+		if err != nil {
+			return &ResolverError{Key: key, Ref: ref, Err: err}
+		}
+
+		c.override(key, resolved)
+	}
+
+	return nil
+}
+
+// resolveRef resolves the passed reference through the resolver of its scheme
+func (c *Comic) resolveRef(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	r, ok := c.resolvers[u.Scheme]
+	if !ok {
+		if r, ok = defaultResolvers[u.Scheme]; !ok {
+			return "", fmt.Errorf("resolver of scheme '%s' not registered", u.Scheme)
+		}
+	}
+
+	return r.Resolve(u)
+}
+
+// resolveEnvRef resolves references to environment variables e.g. env://DB_PASSWORD
+func resolveEnvRef(ref *url.URL) (string, error) {
+	value, ok := lookupEnvVar(ref.Host + ref.Opaque)
+	if !ok {
+		return "", fmt.Errorf("env var %s not set", ref.Host+ref.Opaque)
+	}
+
+	return value, nil
+}
+
+// resolveFileRef resolves references to files to their (trimmed) contents
+// e.g. file:///run/secrets/db (absolute path) or file://secrets/db (path relative to the working directory)
+func resolveFileRef(ref *url.URL) (string, error) {
+	path := ref.Host + ref.Path + ref.Opaque
+	if path == "" {
+		return "", errors.New("file path empty")
+	}
+
+	content, err := ioutil.ReadFile(filepath.FromSlash(path))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,199 @@
+package comic
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterResolver(t *testing.T) {
+	defer func(original *Comic) { c = original }(c)
+	c = &Comic{}
+
+	RegisterResolver("Vault", ResolverFunc(resolveEnvRef))
+
+	assert.Contains(t, c.resolvers, "vault")
+}
+
+func TestComic_RegisterResolver(t *testing.T) {
+	c := &Comic{}
+
+	c.RegisterResolver("vault", ResolverFunc(resolveEnvRef))
+	c.RegisterResolver("file", ResolverFunc(resolveEnvRef))
+
+	assert.Len(t, c.resolvers, 2)
+	assert.Contains(t, c.resolvers, "vault")
+	assert.Contains(t, c.resolvers, "file")
+}
+
+func TestComic_resolveRefs(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"db": "secret\n",
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"DB_PASS": "env-secret",
+	})
+	defer unset()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/secrets/db" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, "http-secret")
+	}))
+	defer server.Close()
+
+	httpResolver := ResolverFunc(func(ref *url.URL) (string, error) {
+		resp, err := http.Get(server.URL + ref.Path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("status %d", resp.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+
+		return string(body), err
+	})
+
+	cases := []struct {
+		values         map[string]interface{}
+		expectedOutput map[string]interface{}
+		expectedError  error
+	}{
+		{
+			values: map[string]interface{}{
+				"name":                "app",
+				"server.port":         80,
+				"required.main.name":  "ref+env://MISSING",
+				"db.password":         "ref+file://" + filepath.ToSlash(filepath.Join(dir, "db")),
+				"db.env_password":     "ref+env://DB_PASS",
+				"db.service_password": "ref+secrets://service/secrets/db",
+			},
+			expectedOutput: map[string]interface{}{
+				"name":                "app",
+				"server.port":         80,
+				"required.main.name":  "ref+env://MISSING",
+				"db.password":         "secret",
+				"db.env_password":     "env-secret",
+				"db.service_password": "http-secret",
+			},
+			expectedError: nil,
+		},
+		{
+			values: map[string]interface{}{
+				"db.password": "ref+env://MISSING",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password": "ref+env://MISSING",
+			},
+			expectedError: &ResolverError{
+				Key: "db.password",
+				Ref: "env://MISSING",
+				Err: errors.New("env var MISSING not set"),
+			},
+		},
+		{
+			values: map[string]interface{}{
+				"db.password": "ref+secrets://service/secrets/missing",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password": "ref+secrets://service/secrets/missing",
+			},
+			expectedError: &ResolverError{
+				Key: "db.password",
+				Ref: "secrets://service/secrets/missing",
+				Err: errors.New("status 404"),
+			},
+		},
+		{
+			values: map[string]interface{}{
+				"db.password": "ref+vault://db",
+			},
+			expectedOutput: map[string]interface{}{
+				"db.password": "ref+vault://db",
+			},
+			expectedError: &ResolverError{
+				Key: "db.password",
+				Ref: "vault://db",
+				Err: errors.New("resolver of scheme 'vault' not registered"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		keys := make(map[string]bool)
+		for key := range c.values {
+			keys[key] = true
+		}
+
+		vip := &mockViper{
+			keys:   keys,
+			values: c.values,
+		}
+		comic := &Comic{
+			vip: vip,
+		}
+		comic.RegisterResolver("secrets", httpResolver)
+
+		err := comic.resolveRefs()
+
+		assert.Equal(t, c.expectedOutput, vip.values)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestResolverError(t *testing.T) {
+	err := error(&ResolverError{
+		Key: "db.password",
+		Ref: "env://DB_PASS",
+		Err: errors.New("env var DB_PASS not set"),
+	})
+
+	var resolverErr *ResolverError
+
+	assert.EqualError(t, err, "config 'db.password' not resolved from env://DB_PASS: env var DB_PASS not set")
+	assert.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &resolverErr))
+	assert.Equal(t, "db.password", resolverErr.Key)
+	assert.EqualError(t, errors.Unwrap(err), "env var DB_PASS not set")
+}
+
+func TestComic_LoadForCommand_refs(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: ref+env://APP_NAME
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	unset := setEnvVars(map[string]string{
+		"APP_NAME": "app",
+	})
+	defer unset()
+
+	cfg := &strictConfig{}
+	assert.NoError(t, NewWithOptions(Options{ConfigFilePath: dir}).Load(cfg))
+	assert.Equal(t, "app", cfg.Name)
+
+	unset()
+
+	err := NewWithOptions(Options{ConfigFilePath: dir}).Load(&strictConfig{})
+	assert.Equal(t, &ResolverError{Key: "name", Ref: "env://APP_NAME", Err: errors.New("env var APP_NAME not set")}, err)
+}
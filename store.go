@@ -0,0 +1,115 @@
+package comic
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the config of a command loaded into a T, as snapshots that are swapped atomically on reload
+// it's safe to use from multiple goroutines
+type Store[T any] struct {
+	comic       *Comic
+	commandName string
+	current     atomic.Pointer[T]
+	// mutex serializes reloads & (un)subscriptions
+	mutex       sync.Mutex
+	subscribers map[int]func(old, new *T)
+	nextID      int
+}
+
+// NewStore creates a new store of the config of the passed command, loaded (as LoadForCommand does) into a T
+// through package-level exported Comic
+// an error is returned if config can't be loaded
+func NewStore[T any](commandName string) (*Store[T], error) {
+	return NewStoreWithComic[T](c, commandName)
+}
+
+// NewStoreWithComic creates a new store of the config of the passed command, loaded (as LoadForCommand does) into a T
+// through the passed instance of Comic
+// an error is returned if config can't be loaded
+//
+// note: Reload must not be called while config is loaded through the same instance of Comic elsewhere
+// (e.g. while watching it), the loaded structs should be passed to Set instead
+func NewStoreWithComic[T any](c *Comic, commandName string) (*Store[T], error) {
+	s := &Store[T]{comic: c, commandName: commandName}
+
+	cfg := new(T)
+	if err := c.LoadForCommand(cfg, commandName); err != nil {
+		return nil, err
+	}
+
+	s.current.Store(cfg)
+
+	return s, nil
+}
+
+// Get returns the current snapshot of config
+//
+// note: snapshots must not be modified, as they're shared
+func (s *Store[T]) Get() *T {
+	return s.current.Load()
+}
+
+// Reload loads config into a new snapshot, which replaces the current one only if it's loaded successfully,
+// then passes the replaced & the new snapshots to all subscribers
+// an error is returned (leaving the current snapshot in effect) if config can't be loaded
+func (s *Store[T]) Reload() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cfg := new(T)
+	if err := s.comic.LoadForCommand(cfg, s.commandName); err != nil {
+		return err
+	}
+
+	s.swap(cfg)
+
+	return nil
+}
+
+// Set replaces the current snapshot with the passed (already loaded) config,
+// then passes the replaced & the new snapshots to all subscribers
+// e.g. to publish the structs passed to the callbacks of Watch or ReloadOnSIGHUP, instead of loading config again
+func (s *Store[T]) Set(cfg *T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.swap(cfg)
+}
+
+// swap replaces the current snapshot with the passed one & notifies all subscribers (in the order of subscription)
+//
+// note: the mutex must be held
+func (s *Store[T]) swap(cfg *T) {
+	old := s.current.Swap(cfg)
+
+	for id := 0; id < s.nextID; id++ {
+		if subscriber, ok := s.subscribers[id]; ok {
+			subscriber(old, cfg)
+		}
+	}
+}
+
+// Subscribe registers the passed function to be called with the replaced & the new snapshots on each reload
+// (or Set) in the order of subscription, until the returned function is called
+//
+// note: subscribers must not call Reload, Set, Subscribe or the functions it returns
+func (s *Store[T]) Subscribe(subscriber func(old, new *T)) (unsubscribe func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = make(map[int]func(old, new *T))
+	}
+
+	id := s.nextID
+	s.subscribers[id] = subscriber
+	s.nextID++
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		delete(s.subscribers, id)
+	}
+}
@@ -0,0 +1,153 @@
+package comic
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStore(t *testing.T) {
+	defer func(original *Comic) { c = original }(c)
+
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: a",
+	})
+	defer remove()
+
+	c = NewWithOptions(Options{ConfigFilePath: dir})
+
+	s, err := NewStore[layeredConfig]("main")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &layeredConfig{Name: "a"}, s.Get())
+}
+
+func TestStore(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: a
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	write := func(content string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644))
+	}
+
+	s, err := NewStoreWithComic[layeredConfig](NewWithOptions(Options{ConfigFilePath: dir}), "main")
+	assert.NoError(t, err)
+
+	first := s.Get()
+	assert.Equal(t, &layeredConfig{Name: "a"}, first)
+
+	var changes [][2]string
+	unsubscribe := s.Subscribe(func(old, new *layeredConfig) {
+		changes = append(changes, [2]string{old.Name, new.Name})
+	})
+	s.Subscribe(func(old, new *layeredConfig) {
+		changes = append(changes, [2]string{"second", new.Name})
+	})
+
+	write("name: b\nrequired:\n  main:\n    name:\n")
+	assert.NoError(t, s.Reload())
+	assert.Equal(t, &layeredConfig{Name: "b"}, s.Get())
+	assert.Equal(t, &layeredConfig{Name: "a"}, first)
+
+	write("required:\n  main:\n    name:\n")
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), s.Reload())
+	assert.Equal(t, &layeredConfig{Name: "b"}, s.Get())
+
+	unsubscribe()
+
+	write("name: c\nrequired:\n  main:\n    name:\n")
+	assert.NoError(t, s.Reload())
+	assert.Equal(t, &layeredConfig{Name: "c"}, s.Get())
+
+	s.Set(&layeredConfig{Name: "d"})
+	assert.Equal(t, &layeredConfig{Name: "d"}, s.Get())
+
+	assert.Equal(t, [][2]string{{"a", "b"}, {"second", "b"}, {"second", "c"}, {"second", "d"}}, changes)
+}
+
+func TestStore_Set_watch(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: a",
+	})
+	defer remove()
+
+	comic := NewWithOptions(Options{ConfigFilePath: dir})
+
+	s, err := NewStoreWithComic[layeredConfig](comic, "main")
+	assert.NoError(t, err)
+
+	changes := make(chan *layeredConfig, 10)
+	s.Subscribe(func(old, new *layeredConfig) { changes <- new })
+
+	stop, err := comic.Watch(&layeredConfig{}, "main", func(cfg interface{}, err error, _ func()) {
+		if err == nil {
+			s.Set(cfg.(*layeredConfig))
+		}
+	})
+	assert.NoError(t, err)
+	defer stop()
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: b"), 0644))
+
+	select {
+	case cfg := <-changes:
+		assert.Equal(t, &layeredConfig{Name: "b"}, cfg)
+		assert.Equal(t, cfg, s.Get())
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not published")
+	}
+}
+
+func TestStore_concurrentGet(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: a",
+	})
+	defer remove()
+
+	s, err := NewStoreWithComic[layeredConfig](NewWithOptions(Options{ConfigFilePath: dir}), "main")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, "a", s.Get().Name)
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, s.Reload())
+	}
+
+	wg.Wait()
+}
+
+func TestNewStoreWithComic_invalidConfig(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "required:\n  main:\n    name:\n",
+	})
+	defer remove()
+
+	s, err := NewStoreWithComic[layeredConfig](NewWithOptions(Options{ConfigFilePath: dir}), "main")
+
+	assert.Nil(t, s)
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), err)
+}
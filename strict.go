@@ -0,0 +1,87 @@
+package comic
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// unmarshal loads all config variables into the passed struct
+// if StrictKeys is set, the config variables are first checked against the fields of the struct,
+// and an error is returned (leaving the struct untouched) if any of them is unknown
+//
+// note: if cfg isn't a pointer, the error of Viper is returned (regardless of StrictKeys)
+func (c *Comic) unmarshal(cfg interface{}) error {
+	if t := reflect.TypeOf(cfg); c.StrictKeys && t != nil && t.Kind() == reflect.Ptr {
+		if err := c.checkUnknownKeys(cfg); err != nil {
+			return err
+		}
+	}
+
+	return c.vip.Unmarshal(cfg, decodeHook())
+}
+
+// checkUnknownKeys verifies that all config variables (excluding the sections used by Comic itself)
+// map to a field of the passed struct
+func (c *Comic) checkUnknownKeys(cfg interface{}) error {
+	metadata := &mapstructure.Metadata{}
+
+	scratch := reflect.New(reflect.TypeOf(cfg).Elem()).Interface()
+	if err := c.vip.Unmarshal(scratch, decodeHook(), func(dc *mapstructure.DecoderConfig) { dc.Metadata = metadata }); err != nil {
+		return err
+	}
+
+	return getUnknownKeysError(expandKeys(metadata.Unused, c.vip.AllKeys()), getStructKeys(cfg))
+}
+
+// expandKeys replaces each of the passed keys that is a section (e.g. server) with the passed leaf keys under it
+// (e.g. server.host & server.port)
+func expandKeys(keys, leafKeys []string) (expandedKeys []string) {
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		expanded := false
+
+		for _, leafKey := range leafKeys {
+			if strings.HasPrefix(leafKey, key+viperNestedKeySeparator) {
+				expandedKeys = append(expandedKeys, leafKey)
+				expanded = true
+			}
+		}
+
+		if !expanded {
+			expandedKeys = append(expandedKeys, key)
+		}
+	}
+
+	return
+}
+
+// getUnknownKeysError returns an error describing the passed unused (lowercase) keys (excluding the sections used by Comic itself)
+// along with the nearest known key of each, if any
+// if there are no such keys, it returns nil
+func getUnknownKeysError(unusedKeys, knownKeys []string) error {
+	var descriptions []string
+
+	for _, key := range unusedKeys {
+		if isReservedKey(key) {
+			continue
+		}
+
+		if suggestion := suggestKey(key, knownKeys); suggestion != "" {
+			key = fmt.Sprintf("%s (did you mean %s?)", key, suggestion)
+		}
+
+		descriptions = append(descriptions, key)
+	}
+
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	sort.Strings(descriptions)
+
+	return fmt.Errorf("config keys unknown: %s", strings.Join(descriptions, ", "))
+}
@@ -0,0 +1,180 @@
+package comic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type strictConfig struct {
+	Name   string `mapstructure:"NAME"`
+	Server struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	} `mapstructure:"SERVER"`
+}
+
+func TestComic_unmarshal(t *testing.T) {
+	cases := []struct {
+		opts           Options
+		config         string
+		expectedOutput *strictConfig
+		expectedError  error
+	}{
+		{
+			opts: Options{},
+			config: `
+name: app
+sever:
+  port: 80
+`,
+			expectedOutput: &strictConfig{
+				Name: "app",
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				StrictKeys: true,
+			},
+			config: `
+name: app
+server:
+  host: localhost
+  port: 80
+required:
+  main:
+    name:
+aliases:
+  main:
+    - m
+`,
+			expectedOutput: &strictConfig{
+				Name: "app",
+				Server: struct {
+					Host string `mapstructure:"HOST"`
+					Port int    `mapstructure:"PORT"`
+				}{
+					Host: "localhost",
+					Port: 80,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			opts: Options{
+				StrictKeys: true,
+			},
+			config: `
+name: app
+sever:
+  port: 80
+`,
+			expectedOutput: &strictConfig{},
+			expectedError:  errors.New("config keys unknown: sever.port (did you mean server.port?)"),
+		},
+		{
+			opts: Options{
+				StrictKeys: true,
+			},
+			config: `
+name: app
+server:
+  prot: 80
+  url: http://localhost
+`,
+			expectedOutput: &strictConfig{},
+			expectedError:  errors.New("config keys unknown: server.prot (did you mean server.port?), server.url"),
+		},
+	}
+
+	for _, c := range cases {
+		comic := NewWithOptions(c.opts)
+		comic.Viper().SetConfigType("yaml")
+		assert.NoError(t, comic.Viper().ReadConfig(strings.NewReader(c.config)))
+
+		cfg := &strictConfig{}
+		err := comic.unmarshal(cfg)
+
+		assert.Equal(t, c.expectedOutput, cfg)
+		assert.Equal(t, c.expectedError, err)
+	}
+}
+
+func TestComic_LoadForCommand_strictKeysNonPointer(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: app",
+	})
+	defer remove()
+
+	for _, cfg := range []interface{}{strictConfig{}, nil} {
+		comic := NewWithOptions(Options{ConfigFilePath: dir})
+		expected := comic.LoadForCommand(cfg, "main")
+		assert.Error(t, expected)
+
+		comic = NewWithOptions(Options{ConfigFilePath: dir, StrictKeys: true})
+		assert.Equal(t, expected, comic.LoadForCommand(cfg, "main"))
+	}
+}
+
+func TestGetUnknownKeysError(t *testing.T) {
+	knownKeys := []string{"name", "server.host", "server.port"}
+
+	cases := []struct {
+		unusedKeys []string
+		expected   error
+	}{
+		{
+			unusedKeys: nil,
+			expected:   nil,
+		},
+		{
+			unusedKeys: []string{"required", "aliases"},
+			expected:   nil,
+		},
+		{
+			unusedKeys: []string{"server.prot"},
+			expected:   errors.New("config keys unknown: server.prot (did you mean server.port?)"),
+		},
+		{
+			unusedKeys: []string{"ttl", "nmae", "required"},
+			expected:   errors.New("config keys unknown: nmae (did you mean name?), ttl"),
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, getUnknownKeysError(c.unusedKeys, knownKeys))
+	}
+}
+
+func TestExpandKeys(t *testing.T) {
+	leafKeys := []string{"name", "server.host", "server.port", "servers"}
+
+	cases := []struct {
+		keys     []string
+		expected []string
+	}{
+		{
+			keys:     nil,
+			expected: nil,
+		},
+		{
+			keys:     []string{"name"},
+			expected: []string{"name"},
+		},
+		{
+			keys:     []string{"SERVER"},
+			expected: []string{"server.host", "server.port"},
+		},
+		{
+			keys:     []string{"servers", "ttl"},
+			expected: []string{"servers", "ttl"},
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, expandKeys(c.keys, leafKeys))
+	}
+}
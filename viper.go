@@ -1,6 +1,7 @@
 package comic
 
 import (
+	"io"
 	"reflect"
 	"sort"
 	"strings"
@@ -11,33 +12,66 @@ import (
 // comicViper defines the methods of Viper used by Comic
 type comicViper interface {
 	SetConfigName(in string)
+	SetConfigFile(in string)
 	AddConfigPath(in string)
+	SetEnvPrefix(in string)
 	AutomaticEnv()
 	SetEnvKeyReplacer(r *strings.Replacer)
+	BindEnv(input ...string) error
 	ReadInConfig() error
+	ConfigFileUsed() string
+	ReadConfig(in io.Reader) error
+	MergeConfigMap(cfg map[string]interface{}) error
 	Unmarshal(rawVal interface{}, opts ...viper.DecoderConfigOption) error
 	IsSet(key string) bool
+	Get(key string) interface{}
 	AllKeys() []string
+	GetStringMapStringSlice(key string) map[string][]string
+	Set(key string, value interface{})
 }
 
 // mockViper is a Viper stand-in for Comic testing
 type mockViper struct {
-	cfg  interface{}
-	keys map[string]bool
+	cfg       interface{}
+	keys      map[string]bool
+	values    map[string]interface{}
+	boundKeys []string
 }
 
 func (m *mockViper) SetConfigName(in string) {}
 
+func (m *mockViper) SetConfigFile(in string) {}
+
 func (m *mockViper) AddConfigPath(in string) {}
 
+func (m *mockViper) SetEnvPrefix(in string) {}
+
 func (m *mockViper) AutomaticEnv() {}
 
 func (m *mockViper) SetEnvKeyReplacer(r *strings.Replacer) {}
 
+func (m *mockViper) BindEnv(input ...string) error {
+	m.boundKeys = append(m.boundKeys, input[0])
+
+	return nil
+}
+
 func (m *mockViper) ReadInConfig() error {
 	return nil
 }
 
+func (m *mockViper) ConfigFileUsed() string {
+	return ""
+}
+
+func (m *mockViper) ReadConfig(in io.Reader) error {
+	return nil
+}
+
+func (m *mockViper) MergeConfigMap(cfg map[string]interface{}) error {
+	return nil
+}
+
 func (m *mockViper) Unmarshal(rawVal interface{}, opts ...viper.DecoderConfigOption) (err error) {
 	if m.cfg == nil {
 		return
@@ -52,6 +86,10 @@ func (m *mockViper) IsSet(key string) bool {
 	return m.keys[key]
 }
 
+func (m *mockViper) Get(key string) interface{} {
+	return m.values[key]
+}
+
 func (m *mockViper) AllKeys() []string {
 	allKeys := make([]string, len(m.keys))
 
@@ -63,3 +101,22 @@ func (m *mockViper) AllKeys() []string {
 
 	return allKeys
 }
+
+func (m *mockViper) GetStringMapStringSlice(key string) map[string][]string {
+	value, _ := m.values[key].(map[string][]string)
+
+	return value
+}
+
+func (m *mockViper) Set(key string, value interface{}) {
+	if m.keys == nil {
+		m.keys = make(map[string]bool)
+	}
+
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+
+	m.keys[key] = value != nil
+	m.values[key] = value
+}
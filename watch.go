@@ -0,0 +1,253 @@
+package comic
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// delay between the last change of a watched file & reloading config
+const watchDebounceDelay = 100 * time.Millisecond
+
+// Watch loads config for the passed command into the passed struct (as LoadForCommand does),
+// then watches config data files (including overlays & included files), .env files & config directories for changes
+// on changes (debounced), config is loaded again into a new struct, which is passed to the passed function
+// only if it's loaded successfully (i.e. required config variables are present & config is parsed) & differs from the
+// last one, otherwise, the error is passed to it & the last struct stays in effect
+// the returned function stops watching, waiting for the passed function to return (if it's running)
+// the passed function can stop watching itself through the function passed to it, which doesn't wait for it to return
+// an error is returned if config can't be loaded initially or the files can't be watched
+//
+// note: cfg *must* be a pointer
+// note: config must not be loaded through the same instance of Comic while watching
+func Watch(cfg interface{}, commandName string, onChange func(cfg interface{}, err error, stop func())) (stop func(), err error) {
+	return c.Watch(cfg, commandName, onChange)
+}
+func (c *Comic) Watch(cfg interface{}, commandName string, onChange func(cfg interface{}, err error, stop func())) (stop func(), err error) {
+	if err := c.LoadForCommand(cfg, commandName); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	watchedDirs := make(map[string]bool)
+
+	isWatched, err := c.watchDirs(watcher, watchedDirs)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	last := copyStruct(cfg)
+	done, stopped := make(chan struct{}), make(chan struct{})
+
+	var once sync.Once
+	stopWatching := func() { once.Do(func() { close(done) }) }
+
+	notify := func(cfg interface{}, err error) {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		onChange(cfg, err, stopWatching)
+	}
+
+	go func() {
+		defer close(stopped)
+		defer watcher.Close()
+
+		var timer *time.Timer
+		var reload <-chan time.Time
+
+		for {
+			select {
+			case <-done:
+				if timer != nil {
+					timer.Stop()
+				}
+
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if !isWatched(event.Name) {
+					continue
+				}
+
+				if timer == nil {
+					timer = time.NewTimer(watchDebounceDelay)
+				} else {
+					timer.Reset(watchDebounceDelay)
+				}
+
+				reload = timer.C
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				notify(nil, err)
+			case <-reload:
+				reload = nil
+
+				fresh := reflect.New(reflect.TypeOf(cfg).Elem()).Interface()
+				if err := c.LoadForCommand(fresh, commandName); err != nil {
+					notify(nil, err)
+					continue
+				}
+
+				if isWatchedNow, err := c.watchDirs(watcher, watchedDirs); err != nil {
+					notify(nil, err)
+				} else {
+					isWatched = isWatchedNow
+				}
+
+				if reflect.DeepEqual(fresh, last) {
+					continue
+				}
+
+				last = copyStruct(fresh)
+				notify(fresh, nil)
+			}
+		}
+	}()
+
+	return func() {
+		stopWatching()
+		<-stopped
+	}, nil
+}
+
+// watchDirs adds the directories of config data files (including the ones config data file is searched in),
+// .env files & config directories (recursively) that aren't among the passed watched directories (yet)
+// to the passed watcher
+// it returns a function checking if the passed (absolute) path is relevant i.e. config data file (or one it could be
+// found as), an overlay, an included file, a .env file or anything within a config directory
+//
+// note: directories are watched (instead of files), so that files replaced (e.g. by editors or Kubernetes) are noticed
+func (c *Comic) watchDirs(watcher *fsnotify.Watcher, watchedDirs map[string]bool) (isWatched func(path string) bool, err error) {
+	files := make(map[string]bool)
+	searchDirs := make(map[string]bool)
+	var configDirs, dirs []string
+
+	configFile, err := c.getConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	name := c.ConfigFileName
+	if configFile != "" {
+		name = strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+		searchDirs[filepath.Dir(configFile)] = true
+	} else {
+		for _, dir := range c.getConfigFilePaths() {
+			dir, err := filepath.Abs(os.ExpandEnv(dir))
+			if err != nil {
+				return nil, err
+			}
+
+			searchDirs[dir] = true
+		}
+	}
+
+	names := map[string]bool{name: true}
+	for _, overlayName := range c.getOverlayNames(name) {
+		names[overlayName] = true
+	}
+
+	for _, file := range append(append([]string(nil), c.configFiles...), c.EnvFiles...) {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		files[file] = true
+
+		// symlinked files (e.g. of mounted Kubernetes ConfigMaps) change when the first element of their target does
+		if link, err := os.Readlink(file); err == nil && !filepath.IsAbs(link) {
+			files[filepath.Join(filepath.Dir(file), strings.SplitN(filepath.ToSlash(link), "/", 2)[0])] = true
+		}
+
+		dirs = append(dirs, filepath.Dir(file))
+	}
+
+	for dir := range searchDirs {
+		dirs = append(dirs, dir)
+	}
+
+	for _, configDir := range c.ConfigDirs {
+		configDir, err := filepath.Abs(configDir)
+		if err != nil {
+			return nil, err
+		}
+
+		configDirs = append(configDirs, configDir)
+
+		filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return nil
+			}
+
+			if path != configDir && strings.HasPrefix(info.Name(), hiddenFilePrefix) {
+				return filepath.SkipDir
+			}
+
+			dirs = append(dirs, path)
+
+			return nil
+		})
+	}
+
+	for _, dir := range dirs {
+		if watchedDirs[dir] {
+			continue
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := watcher.Add(dir); err != nil {
+			return nil, err
+		}
+
+		watchedDirs[dir] = true
+	}
+
+	return func(path string) bool {
+		if files[path] {
+			return true
+		}
+
+		for _, configDir := range configDirs {
+			if path == configDir || strings.HasPrefix(path, configDir+string(filepath.Separator)) {
+				return true
+			}
+		}
+
+		ext := filepath.Ext(path)
+
+		return searchDirs[filepath.Dir(path)] && isSupportedConfigType(strings.TrimPrefix(ext, ".")) &&
+			names[strings.TrimSuffix(filepath.Base(path), ext)]
+	}, nil
+}
+
+// copyStruct returns a pointer to a (shallow) copy of the struct the passed pointer points to
+func copyStruct(cfg interface{}) interface{} {
+	copied := reflect.New(reflect.TypeOf(cfg).Elem())
+	copied.Elem().Set(reflect.ValueOf(cfg).Elem())
+
+	return copied.Interface()
+}
@@ -0,0 +1,223 @@
+package comic
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// watchResult is a call of the function passed to Watch
+type watchResult struct {
+	cfg interface{}
+	err error
+}
+
+// nextWatchResult returns the next call of the function passed to Watch, failing the test if there is none in time
+func nextWatchResult(t *testing.T, results chan watchResult) watchResult {
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not noticed")
+	}
+
+	return watchResult{}
+}
+
+func TestComic_Watch(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+name: a
+required:
+  main:
+    name:
+`,
+		"secrets/server.host": "localhost",
+	})
+	defer remove()
+
+	write := func(name, content string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	results := make(chan watchResult, 10)
+	comic := NewWithOptions(Options{ConfigFilePath: dir, ConfigDirs: []string{filepath.Join(dir, "secrets")}})
+
+	cfg := &layeredConfig{}
+	stop, err := comic.Watch(cfg, "main", func(cfg interface{}, err error, _ func()) { results <- watchResult{cfg, err} })
+	assert.NoError(t, err)
+	defer stop()
+
+	assert.Equal(t, "a", cfg.Name)
+	assert.Equal(t, "localhost", cfg.Server.Host)
+
+	// changes are debounced
+	write("config.yaml", "name: b\nrequired:\n  main:\n    name:\n")
+	write("config.yaml", "name: c\nrequired:\n  main:\n    name:\n")
+
+	result := nextWatchResult(t, results)
+	assert.NoError(t, result.err)
+	assert.Equal(t, "c", result.cfg.(*layeredConfig).Name)
+	assert.Equal(t, "localhost", result.cfg.(*layeredConfig).Server.Host)
+
+	// invalid changes are reported, leaving the last struct untouched
+	write("config.yaml", "required:\n  main:\n    name:\n")
+
+	result = nextWatchResult(t, results)
+	assert.Nil(t, result.cfg)
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), result.err)
+
+	// config directories & new overlays are watched too
+	write("secrets/server.host", "remote")
+	write("config.local.yaml", "name: d")
+
+	result = nextWatchResult(t, results)
+	assert.NoError(t, result.err)
+	assert.Equal(t, "d", result.cfg.(*layeredConfig).Name)
+	assert.Equal(t, "remote", result.cfg.(*layeredConfig).Server.Host)
+
+	assert.Equal(t, "a", cfg.Name)
+
+	stop()
+	write("config.local.yaml", "name: e")
+
+	select {
+	case result := <-results:
+		t.Errorf("change noticed after stopping: %v", result)
+	case <-time.After(3 * watchDebounceDelay):
+	}
+}
+
+func TestComic_Watch_invalidConfig(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": `
+required:
+  main:
+    name:
+`,
+	})
+	defer remove()
+
+	stop, err := NewWithOptions(Options{ConfigFilePath: dir}).Watch(&layeredConfig{}, "main", func(interface{}, error, func()) {})
+
+	assert.Nil(t, stop)
+	assert.Equal(t, errors.New("required config for command 'main' missing: config not present: name"), err)
+}
+
+func TestComic_Watch_irrelevantChanges(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: a",
+	})
+	defer remove()
+
+	results := make(chan watchResult, 10)
+	comic := NewWithOptions(Options{ConfigFilePath: dir, EnvPrefix: "myapp", StrictEnvVars: true})
+
+	stop, err := comic.Watch(&layeredConfig{}, "main", func(cfg interface{}, err error, _ func()) { results <- watchResult{cfg, err} })
+	assert.NoError(t, err)
+	defer stop()
+
+	// any reload fails from now on
+	unset := setEnvVars(map[string]string{
+		"MYAPP_DEBUG": "true",
+	})
+	defer unset()
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app.log"), []byte("started"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json.bak"), []byte("{}"), 0644))
+
+	select {
+	case result := <-results:
+		t.Errorf("irrelevant change noticed: %v", result)
+	case <-time.After(3 * watchDebounceDelay):
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: b"), 0644))
+
+	result := nextWatchResult(t, results)
+	assert.Equal(t, errors.New("env vars unknown: MYAPP_DEBUG"), result.err)
+}
+
+func TestComic_Watch_optionalConfigFile(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{})
+	defer remove()
+
+	results := make(chan watchResult, 10)
+	comic := NewWithOptions(Options{ConfigFilePath: dir, ConfigFileOptional: true})
+
+	stop, err := comic.Watch(&layeredConfig{}, "main", func(cfg interface{}, err error, _ func()) { results <- watchResult{cfg, err} })
+	assert.NoError(t, err)
+	defer stop()
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: a"), 0644))
+
+	result := nextWatchResult(t, results)
+	assert.NoError(t, result.err)
+	assert.Equal(t, "a", result.cfg.(*layeredConfig).Name)
+}
+
+func TestComic_Watch_stopOnChange(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: a",
+	})
+	defer remove()
+
+	calls := make(chan struct{}, 10)
+
+	stop, err := NewWithOptions(Options{ConfigFilePath: dir}).Watch(&layeredConfig{}, "main", func(_ interface{}, _ error, stop func()) {
+		stop()
+		calls <- struct{}{}
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: b"), 0644))
+
+	select {
+	case <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watching not stopped from within the callback")
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: c"), 0644))
+
+	select {
+	case <-calls:
+		t.Error("change noticed after stopping")
+	case <-time.After(3 * watchDebounceDelay):
+	}
+
+	stop()
+}
+
+func TestComic_Watch_stopDuringChange(t *testing.T) {
+	dir, remove := writeConfigFiles(map[string]string{
+		"config.yaml": "name: a",
+	})
+	defer remove()
+
+	started := make(chan struct{})
+	var returned int32
+
+	stop, err := NewWithOptions(Options{ConfigFilePath: dir}).Watch(&layeredConfig{}, "main", func(interface{}, error, func()) {
+		close(started)
+		time.Sleep(5 * watchDebounceDelay)
+		atomic.StoreInt32(&returned, 1)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: b"), 0644))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not noticed")
+	}
+
+	stop()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&returned), "stopped before the callback returned")
+}